@@ -1,19 +1,145 @@
 package lib
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"math/big"
 	"net/http"
 	"net/url"
+	"sort"
+	"strconv"
 )
 
+const DefaultMaxBodyBytes = 10 << 20
+
+// DefaultOrdersLimit and MaxOrdersLimit bound how many orders /orders returns at once.
+const (
+	DefaultOrdersLimit = 10
+	MaxOrdersLimit     = 1000
+)
+
+// MaxIncomparablePairs bounds how many incomparable pairs are reported at once.
+const MaxIncomparablePairs = 1000
+
+type SortResponse struct {
+	Order   []string       `json:"order"`
+	Levels  [][]string     `json:"levels,omitempty"`
+	Depth   map[string]int `json:"depth,omitempty"`
+	Nodes   []NodeResult   `json:"nodes,omitempty"`
+	Ignored [][]string     `json:"ignored,omitempty"`
+}
+
+type StableResponse struct {
+	Order []string `json:"order"`
+	Moved []string `json:"moved"`
+}
+
+type NodeResult struct {
+	ID    string       `json:"id"`
+	Depth int          `json:"depth"`
+	Attrs Attrs        `json:"attrs,omitempty"`
+	Edges []EdgeResult `json:"edges,omitempty"`
+}
+
+type EdgeResult struct {
+	To    string `json:"to"`
+	Attrs Attrs  `json:"attrs,omitempty"`
+}
+
+type ComponentsResponse struct {
+	Components [][]string      `json:"components"`
+	Links      []ComponentLink `json:"links"`
+}
+
+type ComponentLink struct {
+	From  int        `json:"from"`
+	To    int        `json:"to"`
+	Edges [][]string `json:"edges"`
+}
+
+type CriticalPathResponse struct {
+	Length float64      `json:"length"`
+	Path   []string     `json:"path"`
+	Tasks  []TaskTiming `json:"tasks"`
+}
+
+type TaskTiming struct {
+	ID             string  `json:"id"`
+	Duration       float64 `json:"duration"`
+	EarliestStart  float64 `json:"earliest_start"`
+	EarliestFinish float64 `json:"earliest_finish"`
+	LatestStart    float64 `json:"latest_start"`
+	LatestFinish   float64 `json:"latest_finish"`
+	Slack          float64 `json:"slack"`
+}
+
+type ScheduleResponse struct {
+	Makespan float64        `json:"makespan"`
+	Workers  int            `json:"workers"`
+	Slots    []ScheduleSlot `json:"slots"`
+}
+
+type ScheduleSlot struct {
+	ID     string  `json:"id"`
+	Worker int     `json:"worker"`
+	Start  float64 `json:"start"`
+	Finish float64 `json:"finish"`
+}
+
+type ReductionResponse struct {
+	Edges     [][]string `json:"edges"`
+	Redundant [][]string `json:"redundant"`
+}
+
+type ClosureResponse struct {
+	Reachable []ReachableResult   `json:"reachable,omitempty"`
+	Closure   map[string][]string `json:"closure,omitempty"`
+}
+
+type ReachableResult struct {
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Reachable bool   `json:"reachable"`
+}
+
+type PolicyResponse struct {
+	Violations []LayerViolation[string] `json:"violations"`
+}
+
+type ExplainResponse struct {
+	Relation string   `json:"relation"`
+	Path     []string `json:"path"`
+}
+
+type OrdersResponse struct {
+	Orders [][]string `json:"orders"`
+	Next   string     `json:"next,omitempty"`
+	Count  *big.Int   `json:"count"`
+	Exact  bool       `json:"exact"`
+}
+
+type UniqueResponse struct {
+	Unique       bool        `json:"unique"`
+	Order        []string    `json:"order"`
+	Incomparable [][2]string `json:"incomparable"`
+}
+
+type FeedbackResponse struct {
+	Edges [][]string `json:"edges"`
+	Exact bool       `json:"exact"`
+	Order []string   `json:"order,omitempty"`
+}
+
 type Api struct {
-	Logger *Logger
+	Logger       *Logger
+	MaxBodyBytes int64
 }
 
 func NewApi(logger *Logger) *Api {
 	a := new(Api)
 	a.Logger = logger
+	a.MaxBodyBytes = DefaultMaxBodyBytes
 	return a
 }
 
@@ -21,76 +147,833 @@ func (api *Api) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	api.Logger.Log("incoming", r.Method, r.RequestURI)
 	u, err := url.ParseRequestURI(r.RequestURI)
 	if err != nil {
-		api.Logger.Log(err)
-		http.Error(w, "bad URI", http.StatusBadRequest)
+		api.writeError(w, &ApiError{http.StatusBadRequest, "bad_uri", "bad URI", nil})
 		return
 	}
 
 	switch {
 	case u.Path == "/health":
-		if r.Method != http.MethodGet {
-			http.Error(w, "unsupported method for /health", http.StatusBadRequest)
-			return
+		err = allowMethod(r, http.MethodGet)
+		if err == nil {
+			_, _ = w.Write([]byte("ok\n"))
 		}
-		_, _ = w.Write([]byte("ok\n"))
 	case u.Path == "/sort":
-		if r.Method != http.MethodPost {
-			http.Error(w, "unsupported method for /sort", http.StatusBadRequest)
-			return
+		err = allowMethod(r, http.MethodPost)
+		if err == nil {
+			err = api.sort(w, r)
+		}
+	case u.Path == "/components":
+		err = allowMethod(r, http.MethodPost)
+		if err == nil {
+			err = api.components(w, r)
+		}
+	case u.Path == "/critical-path":
+		err = allowMethod(r, http.MethodPost)
+		if err == nil {
+			err = api.criticalPath(w, r)
+		}
+	case u.Path == "/schedule":
+		err = allowMethod(r, http.MethodPost)
+		if err == nil {
+			err = api.schedule(w, r)
+		}
+	case u.Path == "/ancestors" || u.Path == "/descendants":
+		err = allowMethod(r, http.MethodPost)
+		if err == nil {
+			err = api.relatives(w, r, u.Path == "/ancestors")
+		}
+	case u.Path == "/impact":
+		err = allowMethod(r, http.MethodPost)
+		if err == nil {
+			err = api.impact(w, r)
+		}
+	case u.Path == "/reduce":
+		err = allowMethod(r, http.MethodPost)
+		if err == nil {
+			err = api.reduce(w, r)
+		}
+	case u.Path == "/closure":
+		err = allowMethod(r, http.MethodPost)
+		if err == nil {
+			err = api.closure(w, r)
+		}
+	case u.Path == "/policy":
+		err = allowMethod(r, http.MethodPost)
+		if err == nil {
+			err = api.policy(w, r)
+		}
+	case u.Path == "/explain":
+		err = allowMethod(r, http.MethodPost)
+		if err == nil {
+			err = api.explain(w, r)
+		}
+	case u.Path == "/orders":
+		err = allowMethod(r, http.MethodPost)
+		if err == nil {
+			err = api.orders(w, r)
+		}
+	case u.Path == "/unique":
+		err = allowMethod(r, http.MethodPost)
+		if err == nil {
+			err = api.unique(w, r)
+		}
+	case u.Path == "/feedback":
+		err = allowMethod(r, http.MethodPost)
+		if err == nil {
+			err = api.feedback(w, r)
+		}
+	default:
+		err = ErrNotFound
+	}
+
+	if err != nil {
+		api.writeError(w, err)
+	}
+}
+
+func allowMethod(r *http.Request, method string) error {
+	if r.Method != method {
+		return &MethodError{Method: r.Method, Path: r.URL.Path, Allow: method}
+	}
+	return nil
+}
+
+// readGraph decodes the request document in the body into a graph,
+// returning the document as well for its options.
+func (api *Api) readGraph(w http.ResponseWriter, r *http.Request) (*Graph[string], *Request, error) {
+	// ties are broken lexicographically unless input order is requested
+	graph := NewGraph[string]()
+	order := r.URL.Query().Get("order")
+	switch order {
+	case "", "lexical":
+		graph.Less = func(a, b string) bool {
+			return a < b
+		}
+	case "input":
+	default:
+		return nil, nil, &OptionError{Name: "order", Value: order}
+	}
+
+	// decode input
+	req, err := DecodeRequest(http.MaxBytesReader(w, r.Body, api.MaxBodyBytes))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// build graph
+	for _, node := range req.Nodes {
+		graph.SetVertexAttrs(node.ID, node.Attrs)
+		if node.Duration != nil {
+			graph.SetWeight(node.ID, *node.Duration)
+		}
+		if node.Priority != nil {
+			graph.SetPriority(node.ID, *node.Priority)
+		}
+		if node.Layer != "" {
+			graph.SetLayer(node.ID, node.Layer)
+		}
+		if node.Pin != "" {
+			graph.SetPin(node.ID, node.Pin)
+		}
+		if node.Level != nil {
+			graph.SetLevelPin(node.ID, *node.Level)
+		}
+	}
+	for _, edge := range req.Edges {
+		if !edge.Soft {
+			graph.SetEdgeAttrs(edge.From, edge.To, edge.Attrs)
+		}
+	}
+
+	// soft edges only count when they don't close a cycle
+	for _, edge := range req.Edges {
+		if edge.Soft && graph.AddSoftEdge(edge.From, edge.To) {
+			graph.SetEdgeAttrs(edge.From, edge.To, edge.Attrs)
+		}
+	}
+
+	// check if empty
+	api.Logger.Log("graph size in request:", graph.Vertices.Size)
+	if graph.Vertices.Size == 0 {
+		return nil, nil, ErrEmptyGraph
+	}
+
+	return graph, req, nil
+}
+
+// lookup checks that every vertex named by an option exists in the graph.
+func lookup(graph *Graph[string], option string, vertices []string) error {
+	if len(vertices) == 0 {
+		return &OptionError{Name: option, Value: "", Reason: "expecting at least 1 vertex"}
+	}
+	var unknown []string
+	for _, u := range vertices {
+		if !graph.Vertices.Has(u) {
+			unknown = append(unknown, u)
 		}
+	}
+	if len(unknown) > 0 {
+		return &UnknownVertexError{option, unknown}
+	}
+	return nil
+}
+
+func (api *Api) writeResponse(w http.ResponseWriter, response interface{}) error {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	return enc.Encode(response)
+}
 
-		// decode input
+func (api *Api) writeError(w http.ResponseWriter, err error) {
+	api.Logger.Log(err)
+	apiErr := NewApiError(err)
+	var methodErr *MethodError
+	if errors.As(err, &methodErr) {
+		w.Header().Set("Allow", methodErr.Allow)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(apiErr.Status)
+	_ = json.NewEncoder(w).Encode(apiErr)
+}
+
+func (api *Api) sort(w http.ResponseWriter, r *http.Request) error {
+	mode := r.URL.Query().Get("mode")
+	if mode != "" && mode != "condense" && mode != "priority" && mode != "stable" && mode != "dependents" {
+		return &OptionError{Name: "mode", Value: mode}
+	}
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "flat" && format != "levels" && format != "nodes" {
+		return &OptionError{Name: "format", Value: format}
+	}
+	if mode != "" && mode != "dependents" && format != "" && format != "flat" {
+		return &OptionError{Name: "format", Value: format, Reason: "not supported in " + mode + " mode"}
+	}
+	reverse := false
+	if value := r.URL.Query().Get("reverse"); value != "" {
 		var err error
-		var edges [][]string
-		dec := json.NewDecoder(r.Body)
-		err = dec.Decode(&edges)
+		reverse, err = strconv.ParseBool(value)
 		if err != nil {
-			api.Logger.Log(err)
-			http.Error(w, "error decoding edges input", http.StatusBadRequest)
-			return
+			return &OptionError{Name: "reverse", Value: value}
 		}
+	}
+	if reverse && mode != "" && mode != "dependents" {
+		return &OptionError{Name: "reverse", Value: "true", Reason: "not supported in " + mode + " mode"}
+	}
+	require := r.URL.Query().Get("require")
+	if require != "" && require != "unique" {
+		return &OptionError{Name: "require", Value: require}
+	}
+	if require != "" && mode == "condense" {
+		return &OptionError{Name: "require", Value: require, Reason: "not supported in " + mode + " mode"}
+	}
 
-		// build graph
-		graph := NewGraph[string]()
-		for _, edge := range edges {
-			graph.AddEdge(edge[0], edge[1])
-			if graph.Undirected {
-				api.Logger.Log(errors.New("seeing an undirected graph"))
-				http.Error(w, "seeing an undirected graph", http.StatusBadRequest)
-				return
+	graph, req, err := api.readGraph(w, r)
+	if err != nil {
+		return err
+	}
+	pinned := len(graph.Pins) > 0 || len(graph.LevelPins) > 0
+	if pinned && mode != "" {
+		return &OptionError{Name: "mode", Value: mode, Reason: "not supported with pinned vertices"}
+	}
+	if req.Policy != nil {
+		violations := graph.CheckLayers(req.Policy.Allowed())
+		if len(violations) > 0 {
+			return &PolicyError[string]{violations}
+		}
+	}
+	if req.Only != nil {
+		err = lookup(graph, "only", req.Only)
+		if err != nil {
+			return err
+		}
+		// only an acyclic graph constrains the subset consistently
+		if mode != "condense" {
+			_, err = graph.Order()
+			if err != nil {
+				return err
 			}
 		}
-
-		// check if empty
-		api.Logger.Log("graph size in request:", graph.Vertices.Size)
-		if graph.Vertices.Size == 0 {
-			api.Logger.Log(errors.New("seeing empty graph"))
-			http.Error(w, "seeing empty graph", http.StatusBadRequest)
-			return
+		only := NewSet[string]()
+		for _, u := range req.Only {
+			only.Add(u)
 		}
-
-		// run top sort
-		var response []string
-		for graph.HasNextLevel() {
-			vertices, err := graph.GetLevel()
+		induced := graph.Induced(only)
+		induced.IgnoredEdges = graph.IgnoredEdges
+		graph = induced
+	}
+	if require == "unique" {
+		unique, err := graph.UniqueOrder()
+		if err != nil {
+			return err
+		}
+		if !unique {
+			pairs, err := graph.Incomparable(MaxIncomparablePairs)
 			if err != nil {
-				api.Logger.Log(err)
-				http.Error(w, err.Error(), http.StatusInternalServerError)
-				return
+				return err
 			}
-			response = append(response, vertices...)
+			return &AmbiguousOrderError[string]{pairs}
 		}
+	}
 
-		// write response
-		api.Logger.Log("sorted result", response)
-		enc := json.NewEncoder(w)
-		err = enc.Encode(response)
+	// bare orders have nowhere to list ignored soft edges, so every shape gets them as a header
+	if len(graph.IgnoredEdges) > 0 {
+		ignored, err := json.Marshal(edgePairs(graph.IgnoredEdges))
 		if err != nil {
-			api.Logger.Log(err)
-			http.Error(w, "unexpected error while encoding response", http.StatusInternalServerError)
-			return
+			return err
 		}
+		w.Header().Set("X-Ignored-Edges", string(ignored))
+	}
+	switch mode {
+	case "condense":
+		return api.sortCondensed(w, graph)
+	case "priority":
+		order, err := graph.PriorityOrder()
+		if err != nil {
+			return err
+		}
+		api.Logger.Log("priority result", order)
+		return api.writeResponse(w, order)
+	case "stable":
+		if req.Previous == nil {
+			return &OptionError{Name: "mode", Value: mode, Reason: "expecting a previous order"}
+		}
+		order, moved, err := graph.StableOrder(req.Previous)
+		if err != nil {
+			return err
+		}
+		response := StableResponse{order, moved}
+		if response.Moved == nil {
+			response.Moved = []string{}
+		}
+		api.Logger.Log("stable result", order, moved)
+		return api.writeResponse(w, response)
+	}
+
+	// run top sort
+	var levels [][]string
+	switch {
+	case pinned:
+		levels, err = graph.PinnedLevels()
+	case mode == "dependents":
+		levels, err = graph.DependentsLevels()
+	default:
+		graph.ResetSort()
+		levels, err = graph.Levels()
+	}
+	if err != nil {
+		return err
+	}
+	if reverse {
+		reversed := make([][]string, len(levels))
+		for i, level := range levels {
+			reversed[len(levels)-1-i] = level
+		}
+		levels = reversed
+	}
+	order := flatten(levels)
+
+	// write response
+	api.Logger.Log("sorted result", order)
+	switch format {
+	case "levels":
+		response := levelsResponse(order, levels)
+		response.Ignored = edgePairs(graph.IgnoredEdges)
+		return api.writeResponse(w, response)
+	case "nodes":
+		response := SortResponse{Order: order, Nodes: nodeResults(graph, levels)}
+		response.Ignored = edgePairs(graph.IgnoredEdges)
+		return api.writeResponse(w, response)
 	default:
-		http.NotFound(w, r)
+		return api.writeResponse(w, order)
+	}
+}
+
+func levelsResponse(order []string, levels [][]string) SortResponse {
+	response := SortResponse{Order: order, Levels: levels, Depth: map[string]int{}}
+	for depth, level := range levels {
+		for _, u := range level {
+			response.Depth[u] = depth
+		}
+	}
+	return response
+}
+
+// edgePairs lists edges as from-to pairs of vertices.
+func edgePairs(edges []Edge[string]) [][]string {
+	var pairs [][]string
+	for _, edge := range edges {
+		pairs = append(pairs, []string{edge.From, edge.To})
+	}
+	return pairs
+}
+
+// nodeResults lists sorted vertices along with their metadata and outgoing edges.
+func nodeResults(graph *Graph[string], levels [][]string) []NodeResult {
+	var nodes []NodeResult
+	for depth, level := range levels {
+		for _, u := range level {
+			node := NodeResult{ID: u, Depth: depth, Attrs: graph.VertexAttrs[u]}
+			for _, v := range graph.AdjList[u].Sorted(graph.less) {
+				node.Edges = append(node.Edges, EdgeResult{v, graph.EdgeAttrs[Edge[string]{u, v}]})
+			}
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// sortCondensed sorts a graph that may contain cycles by collapsing each
+// strongly connected component into a group of vertices.
+func (api *Api) sortCondensed(w http.ResponseWriter, graph *Graph[string]) error {
+	condensed, components := graph.Condense()
+	levels, err := condensed.Levels()
+	if err != nil {
+		return err
+	}
+	var response [][]string
+	for _, level := range levels {
+		for _, i := range level {
+			response = append(response, components[i])
+		}
 	}
+
+	api.Logger.Log("condensed result", response)
+	return api.writeResponse(w, response)
+}
+
+func (api *Api) components(w http.ResponseWriter, r *http.Request) error {
+	graph, _, err := api.readGraph(w, r)
+	if err != nil {
+		return err
+	}
+
+	// list knots in topological order
+	components := graph.NonTrivialComponents()
+	response := ComponentsResponse{Links: []ComponentLink{}}
+	owner := map[string]int{}
+	for i := len(components) - 1; i >= 0; i-- {
+		component := components[i]
+		for _, u := range component {
+			owner[u] = len(response.Components)
+		}
+		response.Components = append(response.Components, component)
+	}
+	if response.Components == nil {
+		response.Components = [][]string{}
+	}
+
+	// collect edges running directly between knots
+	links := map[[2]int]*ComponentLink{}
+	for u, adj := range graph.AdjList {
+		from, exists := owner[u]
+		if !exists {
+			continue
+		}
+		for v := range adj.Map {
+			to, exists := owner[v]
+			if !exists || to == from {
+				continue
+			}
+			link, exists := links[[2]int{from, to}]
+			if !exists {
+				link = &ComponentLink{From: from, To: to}
+				links[[2]int{from, to}] = link
+			}
+			link.Edges = append(link.Edges, []string{u, v})
+		}
+	}
+	for _, link := range links {
+		sort.Slice(link.Edges, func(i, j int) bool {
+			if link.Edges[i][0] != link.Edges[j][0] {
+				return graph.less(link.Edges[i][0], link.Edges[j][0])
+			}
+			return graph.less(link.Edges[i][1], link.Edges[j][1])
+		})
+		response.Links = append(response.Links, *link)
+	}
+	sort.Slice(response.Links, func(i, j int) bool {
+		if response.Links[i].From != response.Links[j].From {
+			return response.Links[i].From < response.Links[j].From
+		}
+		return response.Links[i].To < response.Links[j].To
+	})
+
+	api.Logger.Log("components result", response.Components)
+	return api.writeResponse(w, response)
+}
+
+func (api *Api) criticalPath(w http.ResponseWriter, r *http.Request) error {
+	graph, _, err := api.readGraph(w, r)
+	if err != nil {
+		return err
+	}
+	path, timings, err := graph.CriticalPath()
+	if err != nil {
+		return err
+	}
+
+	// list tasks by start time
+	response := CriticalPathResponse{Path: path}
+	for u, t := range timings {
+		response.Tasks = append(response.Tasks, TaskTiming{
+			ID:             u,
+			Duration:       graph.Weight(u),
+			EarliestStart:  t.EarliestStart,
+			EarliestFinish: t.EarliestFinish,
+			LatestStart:    t.LatestStart,
+			LatestFinish:   t.LatestFinish,
+			Slack:          t.Slack,
+		})
+	}
+	sort.Slice(response.Tasks, func(i, j int) bool {
+		a, b := response.Tasks[i], response.Tasks[j]
+		if a.EarliestStart != b.EarliestStart {
+			return a.EarliestStart < b.EarliestStart
+		}
+		return graph.less(a.ID, b.ID)
+	})
+	response.Length = timings[path[len(path)-1]].EarliestFinish
+
+	api.Logger.Log("critical path result", response.Path, response.Length)
+	return api.writeResponse(w, response)
+}
+
+func (api *Api) schedule(w http.ResponseWriter, r *http.Request) error {
+	graph, req, err := api.readGraph(w, r)
+	if err != nil {
+		return err
+	}
+	// a vertex listed more than once holds each resource once
+	tags := map[string][]string{}
+	tagged := map[string]*Set[string]{}
+	for _, node := range req.Nodes {
+		if tagged[node.ID] == nil {
+			tagged[node.ID] = NewSet[string]()
+		}
+		for _, tag := range node.Resources {
+			if !tagged[node.ID].Has(tag) {
+				tagged[node.ID].Add(tag)
+				tags[node.ID] = append(tags[node.ID], tag)
+			}
+		}
+	}
+	slots, err := graph.Schedule(req.Workers, req.Resources, tags)
+	if err != nil {
+		return err
+	}
+
+	response := ScheduleResponse{Workers: req.Workers}
+	for _, slot := range slots {
+		response.Slots = append(response.Slots, ScheduleSlot{slot.Vertex, slot.Worker, slot.Start, slot.Finish})
+		if slot.Finish > response.Makespan {
+			response.Makespan = slot.Finish
+		}
+	}
+
+	api.Logger.Log("schedule result", response.Makespan)
+	return api.writeResponse(w, response)
+}
+
+// relatives lists the transitive dependencies, or dependents, of the targets.
+func (api *Api) relatives(w http.ResponseWriter, r *http.Request, ancestors bool) error {
+	graph, req, err := api.readGraph(w, r)
+	if err != nil {
+		return err
+	}
+	err = lookup(graph, "targets", req.Targets)
+	if err != nil {
+		return err
+	}
+
+	relatives := graph.Descendants(req.Targets...)
+	if ancestors {
+		relatives = graph.Ancestors(req.Targets...)
+	}
+	response, err := graph.OrderOf(relatives)
+	if err != nil {
+		return err
+	}
+	if response == nil {
+		response = []string{}
+	}
+
+	api.Logger.Log("relatives result", response)
+	return api.writeResponse(w, response)
+}
+
+// impact sorts the changed vertices and everything downstream of them.
+func (api *Api) impact(w http.ResponseWriter, r *http.Request) error {
+	graph, req, err := api.readGraph(w, r)
+	if err != nil {
+		return err
+	}
+	err = lookup(graph, "changed", req.Changed)
+	if err != nil {
+		return err
+	}
+
+	affected := graph.Descendants(req.Changed...)
+	for _, u := range req.Changed {
+		affected.Add(u)
+	}
+	levels, err := graph.Subgraph(affected).Levels()
+	if err != nil {
+		return err
+	}
+	order := flatten(levels)
+
+	api.Logger.Log("impact result", order)
+	return api.writeResponse(w, levelsResponse(order, levels))
+}
+
+// reduce drops every edge implied by a longer path, reporting those dropped.
+func (api *Api) reduce(w http.ResponseWriter, r *http.Request) error {
+	graph, _, err := api.readGraph(w, r)
+	if err != nil {
+		return err
+	}
+	removed, err := graph.TransitiveReduction()
+	if err != nil {
+		return err
+	}
+	order, err := graph.Order()
+	if err != nil {
+		return err
+	}
+
+	// list the remaining edges in topological order of their endpoints
+	position := map[string]int{}
+	for i, u := range order {
+		position[u] = i
+	}
+	response := ReductionResponse{Edges: [][]string{}, Redundant: [][]string{}}
+	for _, u := range order {
+		for _, v := range graph.AdjList[u].Sorted(func(a, b string) bool { return position[a] < position[b] }) {
+			response.Edges = append(response.Edges, []string{u, v})
+		}
+	}
+	for _, edge := range removed {
+		response.Redundant = append(response.Redundant, []string{edge.From, edge.To})
+	}
+
+	api.Logger.Log("reduce result", response.Redundant)
+	return api.writeResponse(w, response)
+}
+
+// closure answers reachability queries in bulk, or exports the
+// whole transitive closure when no queries are given.
+func (api *Api) closure(w http.ResponseWriter, r *http.Request) error {
+	graph, req, err := api.readGraph(w, r)
+	if err != nil {
+		return err
+	}
+	var response ClosureResponse
+	if len(req.Queries) == 0 {
+		response.Closure = map[string][]string{}
+		for u, reachable := range graph.TransitiveClosure() {
+			response.Closure[u] = reachable.Sorted(graph.less)
+			if response.Closure[u] == nil {
+				response.Closure[u] = []string{}
+			}
+		}
+		return api.writeResponse(w, response)
+	}
+
+	var vertices []string
+	for _, query := range req.Queries {
+		vertices = append(vertices, query...)
+	}
+	err = lookup(graph, "queries", vertices)
+	if err != nil {
+		return err
+	}
+	descendants := map[string]*Set[string]{}
+	for _, query := range req.Queries {
+		from, to := query[0], query[1]
+		if descendants[from] == nil {
+			descendants[from] = graph.Descendants(from)
+		}
+		response.Reachable = append(response.Reachable, ReachableResult{from, to, descendants[from].Has(to)})
+	}
+
+	api.Logger.Log("closure result", response.Reachable)
+	return api.writeResponse(w, response)
+}
+
+// policy reports every dependency breaking the layering policy, without sorting.
+func (api *Api) policy(w http.ResponseWriter, r *http.Request) error {
+	graph, req, err := api.readGraph(w, r)
+	if err != nil {
+		return err
+	}
+	if req.Policy == nil {
+		return &OptionError{Name: "policy", Value: "", Reason: "expecting a layering policy"}
+	}
+
+	response := PolicyResponse{graph.CheckLayers(req.Policy.Allowed())}
+	if response.Violations == nil {
+		response.Violations = []LayerViolation[string]{}
+	}
+
+	api.Logger.Log("policy result", len(response.Violations))
+	return api.writeResponse(w, response)
+}
+
+func (api *Api) explain(w http.ResponseWriter, r *http.Request) error {
+	graph, req, err := api.readGraph(w, r)
+	if err != nil {
+		return err
+	}
+	if len(req.Targets) != 2 || req.Targets[0] == req.Targets[1] {
+		return &OptionError{Name: "targets", Value: "", Reason: "expecting 2 distinct vertices"}
+	}
+	err = lookup(graph, "targets", req.Targets)
+	if err != nil {
+		return err
+	}
+
+	// paths only force an order on acyclic graphs
+	_, err = graph.Order()
+	if err != nil {
+		return err
+	}
+	a, b := req.Targets[0], req.Targets[1]
+	response := ExplainResponse{"before", graph.Path(a, b)}
+	if response.Path == nil {
+		response = ExplainResponse{"after", graph.Path(b, a)}
+	}
+	if response.Path == nil {
+		response = ExplainResponse{"unordered", []string{}}
+	}
+
+	api.Logger.Log("explain result", response.Relation, response.Path)
+	return api.writeResponse(w, response)
+}
+
+func (api *Api) orders(w http.ResponseWriter, r *http.Request) error {
+	limit := DefaultOrdersLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > MaxOrdersLimit {
+			return &OptionError{Name: "limit", Value: value, Reason: "expecting 1 to " + strconv.Itoa(MaxOrdersLimit) + " orders"}
+		}
+	}
+
+	graph, _, err := api.readGraph(w, r)
+	if err != nil {
+		return err
+	}
+	cursor := r.URL.Query().Get("cursor")
+	after, err := decodeCursor(graph, cursor)
+	if err != nil {
+		return err
+	}
+
+	var response OrdersResponse
+	orders, more, err := graph.Orders(after, limit)
+	if err != nil {
+		return err
+	}
+	response.Orders = orders
+	if response.Orders == nil {
+		response.Orders = [][]string{}
+	}
+	if more {
+		response.Next = encodeCursor(orders[len(orders)-1])
+	}
+	response.Count, response.Exact, err = graph.CountOrders()
+	if err != nil {
+		return err
+	}
+
+	api.Logger.Log("orders result", len(response.Orders), response.Count)
+	return api.writeResponse(w, response)
+}
+
+// cursors are the last order of a page, so the next page resumes after it
+func encodeCursor(order []string) string {
+	b, _ := json.Marshal(order)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(graph *Graph[string], cursor string) ([]string, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+	err := &OptionError{Name: "cursor", Value: cursor, Reason: "expecting the next cursor of a previous response"}
+	b, decodeErr := base64.RawURLEncoding.DecodeString(cursor)
+	if decodeErr != nil {
+		return nil, err
+	}
+	var order []string
+	if json.Unmarshal(b, &order) != nil || len(order) != graph.Vertices.Size {
+		return nil, err
+	}
+	for _, u := range order {
+		if !graph.Vertices.Has(u) {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+func (api *Api) unique(w http.ResponseWriter, r *http.Request) error {
+	graph, _, err := api.readGraph(w, r)
+	if err != nil {
+		return err
+	}
+
+	var response UniqueResponse
+	response.Order, err = graph.Order()
+	if err != nil {
+		return err
+	}
+	response.Incomparable, err = graph.Incomparable(MaxIncomparablePairs)
+	if err != nil {
+		return err
+	}
+	response.Unique = len(response.Incomparable) == 0
+	if response.Incomparable == nil {
+		response.Incomparable = [][2]string{}
+	}
+
+	api.Logger.Log("unique result", response.Unique)
+	return api.writeResponse(w, response)
+}
+
+func (api *Api) feedback(w http.ResponseWriter, r *http.Request) error {
+	repair := false
+	if value := r.URL.Query().Get("sort"); value != "" {
+		var err error
+		repair, err = strconv.ParseBool(value)
+		if err != nil {
+			return &OptionError{Name: "sort", Value: value}
+		}
+	}
+
+	graph, _, err := api.readGraph(w, r)
+	if err != nil {
+		return err
+	}
+
+	edges, exact := graph.FeedbackEdges()
+	response := FeedbackResponse{Edges: [][]string{}, Exact: exact}
+	for _, edge := range edges {
+		response.Edges = append(response.Edges, []string{edge.From, edge.To})
+	}
+
+	// sort what's left once the edges are removed
+	if repair {
+		for _, edge := range edges {
+			graph.RemoveEdge(edge.From, edge.To)
+		}
+		response.Order, err = graph.Order()
+		if err != nil {
+			return err
+		}
+	}
+
+	api.Logger.Log("feedback result", response.Edges)
+	return api.writeResponse(w, response)
 }
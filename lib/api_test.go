@@ -3,12 +3,25 @@ package lib
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http/httptest"
 	"strings"
 	"testing"
 )
 
+func readApiError(t *testing.T, res *httptest.ResponseRecorder) *ApiError {
+	if res.Header().Get("Content-Type") != "application/json" {
+		t.Fatal("expecting json error body")
+	}
+	apiErr := new(ApiError)
+	err := json.NewDecoder(res.Body).Decode(apiErr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return apiErr
+}
+
 func TestApi_ServeHTTP_UriParseFailure(t *testing.T) {
 	req := httptest.NewRequest("GET", "/willRemove", nil)
 	req.RequestURI = ""
@@ -21,8 +34,13 @@ func TestApi_ServeHTTP_UriParseFailure(t *testing.T) {
 	if res.Code != 400 {
 		t.Fatal("expecting 400")
 	}
-	if res.Body.String() != "bad URI\n" {
-		t.Fatal("expecting message re: bad URI")
+	apiErr := readApiError(t, res)
+	if apiErr.Code != "bad_uri" {
+		t.Fatal("expecting code bad_uri")
+	}
+	expected := "bad URI"
+	if apiErr.Message != expected {
+		t.Fatal("expecting msg", expected)
 	}
 }
 
@@ -55,11 +73,15 @@ func TestApi_ServeHTTP_Health_MethodError(t *testing.T) {
 	logger.TestMode = true
 	api := NewApi(logger)
 	api.ServeHTTP(res, req)
-	if res.Code != 400 {
-		t.Fatal("expecting 400")
+	if res.Code != 405 {
+		t.Fatal("expecting 405")
 	}
-	expected := "unsupported method for /health\n"
-	if res.Body.String() != expected {
+	apiErr := readApiError(t, res)
+	if apiErr.Code != "method_not_allowed" {
+		t.Fatal("expecting code method_not_allowed")
+	}
+	expected := "unsupported method for /health"
+	if apiErr.Message != expected {
 		t.Fatal("expecting msg", expected)
 	}
 }
@@ -72,11 +94,15 @@ func TestApi_ServeHTTP_Sort_MethodError(t *testing.T) {
 	logger.TestMode = true
 	api := NewApi(logger)
 	api.ServeHTTP(res, req)
-	if res.Code != 400 {
-		t.Fatal("expecting 400")
+	if res.Code != 405 {
+		t.Fatal("expecting 405")
 	}
-	expected := "unsupported method for /sort\n"
-	if res.Body.String() != expected {
+	apiErr := readApiError(t, res)
+	if apiErr.Code != "method_not_allowed" {
+		t.Fatal("expecting code method_not_allowed")
+	}
+	expected := "unsupported method for /sort"
+	if apiErr.Message != expected {
 		t.Fatal("expecting msg", expected)
 	}
 }
@@ -93,12 +119,42 @@ func TestApi_ServeHTTP_Sort_JsonDecodeError(t *testing.T) {
 	if res.Code != 400 {
 		t.Fatal("expecting 400")
 	}
-	expected := "error decoding edges input\n"
-	if res.Body.String() != expected {
+	apiErr := readApiError(t, res)
+	if apiErr.Code != "invalid_json" {
+		t.Fatal("expecting code invalid_json")
+	}
+	expected := "error decoding edges input"
+	if apiErr.Message != expected {
 		t.Fatal("expecting msg", expected)
 	}
 }
 
+func TestApi_ServeHTTP_Sort_InvalidEdges(t *testing.T) {
+	reader := bytes.NewReader([]byte(`[["a", "b"], ["a"], [], ["a", "b", "c"], ["a", 1], ["", "b"]]`))
+	req := httptest.NewRequest("POST", "/sort", reader)
+	res := httptest.NewRecorder()
+
+	logger := NewLogger()
+	logger.TestMode = true
+	api := NewApi(logger)
+	api.ServeHTTP(res, req)
+	if res.Code != 400 {
+		t.Fatal("expecting 400")
+	}
+	apiErr := readApiError(t, res)
+	if apiErr.Code != "invalid_input" {
+		t.Fatal("expecting code invalid_input")
+	}
+	edges := apiErr.Details.(map[string]interface{})["edges"].([]interface{})
+	var indexes []interface{}
+	for _, edge := range edges {
+		indexes = append(indexes, edge.(map[string]interface{})["index"])
+	}
+	if fmt.Sprint(indexes) != "[1 2 3 4 5]" {
+		t.Fatal("expecting every bad edge to be reported, got", indexes)
+	}
+}
+
 func TestApi_ServeHTTP_Sort_UndirectedGraph(t *testing.T) {
 	edges := [][]string{
 		{"a", "b"},
@@ -117,11 +173,38 @@ func TestApi_ServeHTTP_Sort_UndirectedGraph(t *testing.T) {
 	logger.TestMode = true
 	api := NewApi(logger)
 	api.ServeHTTP(res, req)
-	if res.Code != 400 {
-		t.Fatal("expecting 400")
+	if res.Code != 422 {
+		t.Fatal("expecting 422")
 	}
-	expected := "seeing an undirected graph\n"
-	if res.Body.String() != expected {
+	apiErr := readApiError(t, res)
+	if apiErr.Code != "cycle_detected" {
+		t.Fatal("expecting code cycle_detected")
+	}
+	cycle := apiErr.Details.(map[string]interface{})["cycle"].([]interface{})
+	if len(cycle) != 3 || cycle[0] != cycle[2] {
+		t.Fatal("expecting 2-cycle, got", cycle)
+	}
+}
+
+func TestApi_ServeHTTP_Sort_BodyTooLarge(t *testing.T) {
+	reader := bytes.NewReader([]byte(`[["a", "b"], ["b", "c"], ["c", "d"]]`))
+	req := httptest.NewRequest("POST", "/sort", reader)
+	res := httptest.NewRecorder()
+
+	logger := NewLogger()
+	logger.TestMode = true
+	api := NewApi(logger)
+	api.MaxBodyBytes = 16
+	api.ServeHTTP(res, req)
+	if res.Code != 413 {
+		t.Fatal("expecting 413")
+	}
+	apiErr := readApiError(t, res)
+	if apiErr.Code != "body_too_large" {
+		t.Fatal("expecting code body_too_large")
+	}
+	expected := "request body too large"
+	if apiErr.Message != expected {
 		t.Fatal("expecting msg", expected)
 	}
 }
@@ -138,8 +221,12 @@ func TestApi_ServeHTTP_Sort_EmptyGraph(t *testing.T) {
 	if res.Code != 400 {
 		t.Fatal("expecting 400")
 	}
-	expected := "seeing empty graph\n"
-	if res.Body.String() != expected {
+	apiErr := readApiError(t, res)
+	if apiErr.Code != "empty_graph" {
+		t.Fatal("expecting code empty_graph")
+	}
+	expected := "seeing empty graph"
+	if apiErr.Message != expected {
 		t.Fatal("expecting msg", expected)
 	}
 }
@@ -164,12 +251,20 @@ func TestApi_ServeHTTP_Sort_CycleDetection(t *testing.T) {
 	api := NewApi(logger)
 	api.ServeHTTP(res, req)
 
-	if res.Code != 500 {
-		t.Fatal("expecting 500")
+	if res.Code != 422 {
+		t.Fatal("expecting 422")
 	}
-	expected := "cycle detected\n"
-	if res.Body.String() != expected {
-		t.Fatal("expecting", expected)
+	apiErr := readApiError(t, res)
+	if apiErr.Code != "cycle_detected" {
+		t.Fatal("expecting code cycle_detected")
+	}
+	if apiErr.Message != "cycle detected" {
+		t.Fatal("expecting cycle detected error")
+	}
+	expected := "[a b c a]"
+	cycle := apiErr.Details.(map[string]interface{})["cycle"]
+	if fmt.Sprint(cycle) != expected {
+		t.Fatal("expecting cycle", expected, "got", cycle)
 	}
 }
 
@@ -211,16 +306,1044 @@ func TestApi_ServeHTTP_Sort(t *testing.T) {
 	}
 }
 
-func TestApi_ServeHTTP_404(t *testing.T) {
-	req := httptest.NewRequest("GET", "/does-not-exist", nil)
+func TestApi_ServeHTTP_Sort_ObjectForm(t *testing.T) {
+	body := []byte(`{"nodes": ["lint", "a"], "edges": [["a", "b"], ["b", "c"]]}`)
+	req := httptest.NewRequest("POST", "/sort", bytes.NewReader(body))
 	res := httptest.NewRecorder()
 
 	logger := NewLogger()
 	logger.TestMode = true
 	api := NewApi(logger)
 	api.ServeHTTP(res, req)
-	if res.Code != 404 {
-		t.Fatal("expecting 404")
+	if res.Code != 200 {
+		t.Fatal("expecting 200")
+	}
+	var payload []string
+	err := json.NewDecoder(res.Body).Decode(&payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "a lint b c"
+	if strings.Join(payload, " ") != expected {
+		t.Fatal("expecting", expected, "got", payload)
+	}
+}
+
+func TestApi_ServeHTTP_Sort_Nodes(t *testing.T) {
+	body := []byte(`{
+		"nodes": [{"id": "b", "attrs": {"owner": "infra", "build": 9007199254740993}}],
+		"edges": [{"from": "a", "to": "b", "attrs": {"kind": "build"}}, ["a", "c"]]
+	}`)
+	req := httptest.NewRequest("POST", "/sort?format=nodes", bytes.NewReader(body))
+	res := httptest.NewRecorder()
+
+	logger := NewLogger()
+	logger.TestMode = true
+	api := NewApi(logger)
+	api.ServeHTTP(res, req)
+	if res.Code != 200 {
+		t.Fatal("expecting 200")
+	}
+	expected := `{"order":["a","b","c"],"nodes":[` +
+		`{"id":"a","depth":0,"edges":[{"to":"b","attrs":{"kind":"build"}},{"to":"c"}]},` +
+		`{"id":"b","depth":1,"attrs":{"build":9007199254740993,"owner":"infra"}},` +
+		`{"id":"c","depth":1}]}` + "\n"
+	if res.Body.String() != expected {
+		t.Fatal("expecting", expected, "got", res.Body.String())
+	}
+}
+
+func TestApi_ServeHTTP_Sort_Priority(t *testing.T) {
+	body := []byte(`{
+		"nodes": [{"id": "db", "priority": 10}, {"id": "auth", "priority": 5}],
+		"edges": [["config", "db"], ["config", "auth"], ["config", "cache"]]
+	}`)
+	req := httptest.NewRequest("POST", "/sort?mode=priority", bytes.NewReader(body))
+	res := httptest.NewRecorder()
+
+	logger := NewLogger()
+	logger.TestMode = true
+	api := NewApi(logger)
+	api.ServeHTTP(res, req)
+	if res.Code != 200 {
+		t.Fatal("expecting 200")
+	}
+	expected := `["config","db","auth","cache"]` + "\n"
+	if res.Body.String() != expected {
+		t.Fatal("expecting", expected, "got", res.Body.String())
+	}
+
+	req = httptest.NewRequest("POST", "/sort?mode=priority&format=levels", bytes.NewReader(body))
+	res = httptest.NewRecorder()
+	api.ServeHTTP(res, req)
+	if res.Code != 400 {
+		t.Fatal("expecting 400")
+	}
+}
+
+func TestApi_ServeHTTP_Sort_ModeError(t *testing.T) {
+	reader := bytes.NewReader([]byte(`[["a", "b"]]`))
+	req := httptest.NewRequest("POST", "/sort?mode=bogus", reader)
+	res := httptest.NewRecorder()
+
+	logger := NewLogger()
+	logger.TestMode = true
+	api := NewApi(logger)
+	api.ServeHTTP(res, req)
+	if res.Code != 400 {
+		t.Fatal("expecting 400")
+	}
+	apiErr := readApiError(t, res)
+	if apiErr.Code != "invalid_option" {
+		t.Fatal("expecting code invalid_option")
+	}
+	expected := "unsupported mode \"bogus\""
+	if apiErr.Message != expected {
+		t.Fatal("expecting msg", expected)
+	}
+}
+
+func TestApi_ServeHTTP_Sort_Condense(t *testing.T) {
+	edges := [][]string{
+		{"a", "b"},
+		{"b", "c"},
+		{"c", "b"},
+		{"c", "d"},
+		{"d", "e"},
+		{"e", "d"},
+	}
+	marshalled, err := json.Marshal(edges)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reader := bytes.NewReader(marshalled)
+	req := httptest.NewRequest("POST", "/sort?mode=condense", reader)
+	res := httptest.NewRecorder()
+
+	logger := NewLogger()
+	logger.TestMode = true
+	api := NewApi(logger)
+	api.ServeHTTP(res, req)
+	if res.Code != 200 {
+		t.Fatal("expecting 200")
+	}
+	var payload [][]string
+	err = json.NewDecoder(res.Body).Decode(&payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var actual []string
+	for _, group := range payload {
+		actual = append(actual, strings.Join(group, ""))
+	}
+	expected := "a bc de"
+	if strings.Join(actual, " ") != expected {
+		t.Fatal("expecting", expected, "got", actual)
+	}
+}
+
+func TestApi_ServeHTTP_Components_MethodError(t *testing.T) {
+	req := httptest.NewRequest("GET", "/components", nil)
+	res := httptest.NewRecorder()
+
+	logger := NewLogger()
+	logger.TestMode = true
+	api := NewApi(logger)
+	api.ServeHTTP(res, req)
+	if res.Code != 405 {
+		t.Fatal("expecting 405")
+	}
+	apiErr := readApiError(t, res)
+	if apiErr.Code != "method_not_allowed" {
+		t.Fatal("expecting code method_not_allowed")
+	}
+	expected := "unsupported method for /components"
+	if apiErr.Message != expected {
+		t.Fatal("expecting msg", expected)
+	}
+}
+
+func TestApi_ServeHTTP_Components(t *testing.T) {
+	edges := [][]string{
+		{"a", "b"},
+		{"b", "a"},
+		{"b", "c"},
+		{"c", "d"},
+		{"d", "e"},
+		{"e", "c"},
+		{"e", "f"},
+	}
+	marshalled, err := json.Marshal(edges)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reader := bytes.NewReader(marshalled)
+	req := httptest.NewRequest("POST", "/components", reader)
+	res := httptest.NewRecorder()
+
+	logger := NewLogger()
+	logger.TestMode = true
+	api := NewApi(logger)
+	api.ServeHTTP(res, req)
+	if res.Code != 200 {
+		t.Fatal("expecting 200")
+	}
+	var payload ComponentsResponse
+	err = json.NewDecoder(res.Body).Decode(&payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(payload.Components) != 2 {
+		t.Fatal("expecting 2 components")
+	}
+	if strings.Join(payload.Components[0], "") != "ab" {
+		t.Fatal("expecting first component ab")
+	}
+	if strings.Join(payload.Components[1], "") != "cde" {
+		t.Fatal("expecting second component cde")
+	}
+	if len(payload.Links) != 1 {
+		t.Fatal("expecting 1 link")
+	}
+	link := payload.Links[0]
+	if link.From != 0 || link.To != 1 || len(link.Edges) != 1 {
+		t.Fatal("expecting single link from 0 to 1")
+	}
+	if strings.Join(link.Edges[0], "") != "bc" {
+		t.Fatal("expecting b->c to link components")
+	}
+}
+
+func TestApi_ServeHTTP_Sort_Order(t *testing.T) {
+	body := []byte(`[["c", "x"], ["b", "x"], ["a", "x"], ["x", "z"], ["x", "y"]]`)
+	logger := NewLogger()
+	logger.TestMode = true
+	api := NewApi(logger)
+
+	cases := map[string]string{
+		"/sort":               `["a","b","c","x","y","z"]`,
+		"/sort?order=lexical": `["a","b","c","x","y","z"]`,
+		"/sort?order=input":   `["c","b","a","x","z","y"]`,
+	}
+	for uri, expected := range cases {
+		// identical input must give byte-identical output
+		for i := 0; i < 10; i++ {
+			req := httptest.NewRequest("POST", uri, bytes.NewReader(body))
+			res := httptest.NewRecorder()
+			api.ServeHTTP(res, req)
+			if res.Code != 200 {
+				t.Fatal("expecting 200")
+			}
+			if res.Body.String() != expected+"\n" {
+				t.Fatal("expecting", expected, "for", uri, "got", res.Body.String())
+			}
+		}
+	}
+}
+
+func TestApi_ServeHTTP_Sort_Levels(t *testing.T) {
+	body := []byte(`[["a", "c"], ["b", "c"], ["c", "d"], ["c", "e"]]`)
+	req := httptest.NewRequest("POST", "/sort?format=levels", bytes.NewReader(body))
+	res := httptest.NewRecorder()
+
+	logger := NewLogger()
+	logger.TestMode = true
+	api := NewApi(logger)
+	api.ServeHTTP(res, req)
+	if res.Code != 200 {
+		t.Fatal("expecting 200")
+	}
+	var payload SortResponse
+	err := json.NewDecoder(res.Body).Decode(&payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Join(payload.Order, "") != "abcde" {
+		t.Fatal("unexpected order", payload.Order)
+	}
+	if fmt.Sprint(payload.Levels) != "[[a b] [c] [d e]]" {
+		t.Fatal("unexpected levels", payload.Levels)
+	}
+	expected := map[string]int{"a": 0, "b": 0, "c": 1, "d": 2, "e": 2}
+	if fmt.Sprint(payload.Depth) != fmt.Sprint(expected) {
+		t.Fatal("unexpected depths", payload.Depth)
+	}
+}
+
+func TestApi_ServeHTTP_Sort_FormatError(t *testing.T) {
+	reader := bytes.NewReader([]byte(`[["a", "b"]]`))
+	req := httptest.NewRequest("POST", "/sort?format=bogus", reader)
+	res := httptest.NewRecorder()
+
+	logger := NewLogger()
+	logger.TestMode = true
+	api := NewApi(logger)
+	api.ServeHTTP(res, req)
+	if res.Code != 400 {
+		t.Fatal("expecting 400")
+	}
+	apiErr := readApiError(t, res)
+	if apiErr.Code != "invalid_option" {
+		t.Fatal("expecting code invalid_option")
+	}
+	expected := "unsupported format \"bogus\""
+	if apiErr.Message != expected {
+		t.Fatal("expecting msg", expected)
+	}
+}
+
+func TestApi_ServeHTTP_Sort_OrderError(t *testing.T) {
+	reader := bytes.NewReader([]byte(`[["a", "b"]]`))
+	req := httptest.NewRequest("POST", "/sort?order=bogus", reader)
+	res := httptest.NewRecorder()
+
+	logger := NewLogger()
+	logger.TestMode = true
+	api := NewApi(logger)
+	api.ServeHTTP(res, req)
+	if res.Code != 400 {
+		t.Fatal("expecting 400")
+	}
+	apiErr := readApiError(t, res)
+	if apiErr.Code != "invalid_option" {
+		t.Fatal("expecting code invalid_option")
+	}
+	expected := "unsupported order \"bogus\""
+	if apiErr.Message != expected {
+		t.Fatal("expecting msg", expected)
+	}
+}
+
+func TestApi_ServeHTTP_CriticalPath(t *testing.T) {
+	body := []byte(`{
+		"nodes": [{"id": "a", "duration": 3}, {"id": "b", "duration": 2}, {"id": "c", "duration": 5}],
+		"edges": [["a", "b"], ["a", "c"]]
+	}`)
+	req := httptest.NewRequest("POST", "/critical-path", bytes.NewReader(body))
+	res := httptest.NewRecorder()
+
+	logger := NewLogger()
+	logger.TestMode = true
+	api := NewApi(logger)
+	api.ServeHTTP(res, req)
+	if res.Code != 200 {
+		t.Fatal("expecting 200")
+	}
+	var payload CriticalPathResponse
+	err := json.NewDecoder(res.Body).Decode(&payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if payload.Length != 8 {
+		t.Fatal("expecting length of 8")
+	}
+	if strings.Join(payload.Path, "") != "ac" {
+		t.Fatal("expecting critical path ac, got", payload.Path)
+	}
+	expected := []TaskTiming{
+		{"a", 3, 0, 3, 0, 3, 0},
+		{"b", 2, 3, 5, 6, 8, 3},
+		{"c", 5, 3, 8, 3, 8, 0},
+	}
+	if fmt.Sprint(payload.Tasks) != fmt.Sprint(expected) {
+		t.Fatal("unexpected tasks", payload.Tasks)
+	}
+}
+
+func TestApi_ServeHTTP_Schedule(t *testing.T) {
+	body := []byte(`{
+		"workers": 2,
+		"resources": {"gpu": 1},
+		"nodes": [
+			{"id": "a", "duration": 2, "resources": ["gpu"]},
+			{"id": "b", "duration": 2, "resources": ["gpu"]}
+		],
+		"edges": [["a", "c"], ["b", "c"]]
+	}`)
+	req := httptest.NewRequest("POST", "/schedule", bytes.NewReader(body))
+	res := httptest.NewRecorder()
+
+	logger := NewLogger()
+	logger.TestMode = true
+	api := NewApi(logger)
+	api.ServeHTTP(res, req)
+	if res.Code != 200 {
+		t.Fatal("expecting 200")
+	}
+	var payload ScheduleResponse
+	err := json.NewDecoder(res.Body).Decode(&payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if payload.Makespan != 5 || payload.Workers != 2 {
+		t.Fatal("expecting makespan of 5 on 2 workers")
+	}
+	expected := "[{a 0 0 2} {b 0 2 4} {c 0 4 5}]"
+	if fmt.Sprint(payload.Slots) != expected {
+		t.Fatal("expecting", expected, "got", payload.Slots)
+	}
+}
+
+func TestApi_ServeHTTP_Schedule_DuplicateNodes(t *testing.T) {
+	body := []byte(`{
+		"workers": 2,
+		"resources": {"gpu": 2},
+		"nodes": [
+			{"id": "a", "resources": ["gpu"]},
+			{"id": "a", "resources": ["gpu"]},
+			{"id": "b", "resources": ["gpu"]}
+		]
+	}`)
+	req := httptest.NewRequest("POST", "/schedule", bytes.NewReader(body))
+	res := httptest.NewRecorder()
+
+	logger := NewLogger()
+	logger.TestMode = true
+	api := NewApi(logger)
+	api.ServeHTTP(res, req)
+	if res.Code != 200 {
+		t.Fatal("expecting 200")
+	}
+	var payload ScheduleResponse
+	err := json.NewDecoder(res.Body).Decode(&payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payload.Makespan != 1 {
+		t.Fatal("expecting a and b to share the gpus, got makespan", payload.Makespan)
+	}
+}
+
+func TestApi_ServeHTTP_Relatives(t *testing.T) {
+	body := []byte(`{
+		"targets": ["lib"],
+		"edges": [["base", "lib"], ["lib", "app"], ["lib", "cli"], ["app", "deploy"], ["tools", "cli"]]
+	}`)
+	logger := NewLogger()
+	logger.TestMode = true
+	api := NewApi(logger)
+
+	cases := map[string]string{
+		"/ancestors":   `["base"]`,
+		"/descendants": `["app","cli","deploy"]`,
+	}
+	for uri, expected := range cases {
+		req := httptest.NewRequest("POST", uri, bytes.NewReader(body))
+		res := httptest.NewRecorder()
+		api.ServeHTTP(res, req)
+		if res.Code != 200 {
+			t.Fatal("expecting 200")
+		}
+		if res.Body.String() != expected+"\n" {
+			t.Fatal("expecting", expected, "for", uri, "got", res.Body.String())
+		}
+	}
+}
+
+func TestApi_ServeHTTP_Relatives_UnknownTarget(t *testing.T) {
+	body := []byte(`{"targets": ["a", "x"], "edges": [["a", "b"]]}`)
+	req := httptest.NewRequest("POST", "/descendants", bytes.NewReader(body))
+	res := httptest.NewRecorder()
+
+	logger := NewLogger()
+	logger.TestMode = true
+	api := NewApi(logger)
+	api.ServeHTTP(res, req)
+	if res.Code != 400 {
+		t.Fatal("expecting 400")
+	}
+	apiErr := readApiError(t, res)
+	if apiErr.Code != "unknown_vertex" {
+		t.Fatal("expecting code unknown_vertex")
+	}
+	vertices := apiErr.Details.(map[string]interface{})["vertices"]
+	if fmt.Sprint(vertices) != "[x]" {
+		t.Fatal("expecting x to be reported, got", vertices)
+	}
+}
+
+func TestApi_ServeHTTP_Impact(t *testing.T) {
+	body := []byte(`{
+		"changed": ["lib", "docs"],
+		"edges": [
+			["base", "lib"], ["lib", "app"], ["lib", "cli"], ["app", "deploy"],
+			["cli", "deploy"], ["tools", "cli"], ["docs", "site"]
+		]
+	}`)
+	req := httptest.NewRequest("POST", "/impact", bytes.NewReader(body))
+	res := httptest.NewRecorder()
+
+	logger := NewLogger()
+	logger.TestMode = true
+	api := NewApi(logger)
+	api.ServeHTTP(res, req)
+	if res.Code != 200 {
+		t.Fatal("expecting 200")
+	}
+	var payload SortResponse
+	err := json.NewDecoder(res.Body).Decode(&payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "[[docs lib] [app cli site] [deploy]]"
+	if fmt.Sprint(payload.Levels) != expected {
+		t.Fatal("expecting", expected, "got", payload.Levels)
+	}
+	if strings.Join(payload.Order, " ") != "docs lib app cli site deploy" {
+		t.Fatal("unexpected order", payload.Order)
+	}
+	if payload.Depth["deploy"] != 2 {
+		t.Fatal("expecting deploy at depth 2")
+	}
+}
+
+func TestApi_ServeHTTP_Reduce(t *testing.T) {
+	body := []byte(`[["a", "b"], ["b", "c"], ["a", "c"], ["c", "d"], ["a", "d"]]`)
+	req := httptest.NewRequest("POST", "/reduce", bytes.NewReader(body))
+	res := httptest.NewRecorder()
+
+	logger := NewLogger()
+	logger.TestMode = true
+	api := NewApi(logger)
+	api.ServeHTTP(res, req)
+	if res.Code != 200 {
+		t.Fatal("expecting 200")
+	}
+	expected := `{"edges":[["a","b"],["b","c"],["c","d"]],"redundant":[["a","c"],["a","d"]]}` + "\n"
+	if res.Body.String() != expected {
+		t.Fatal("expecting", expected, "got", res.Body.String())
+	}
+}
+
+func TestApi_ServeHTTP_Closure(t *testing.T) {
+	logger := NewLogger()
+	logger.TestMode = true
+	api := NewApi(logger)
+
+	body := []byte(`[["ui", "service"], ["service", "db"], ["tools", "db"]]`)
+	req := httptest.NewRequest("POST", "/closure", bytes.NewReader(body))
+	res := httptest.NewRecorder()
+	api.ServeHTTP(res, req)
+	if res.Code != 200 {
+		t.Fatal("expecting 200")
+	}
+	expected := `{"closure":{"db":[],"service":["db"],"tools":["db"],"ui":["db","service"]}}` + "\n"
+	if res.Body.String() != expected {
+		t.Fatal("expecting", expected, "got", res.Body.String())
+	}
+
+	body = []byte(`{
+		"queries": [["ui", "db"], ["db", "ui"], ["tools", "service"]],
+		"edges": [["ui", "service"], ["service", "db"], ["tools", "db"]]
+	}`)
+	req = httptest.NewRequest("POST", "/closure", bytes.NewReader(body))
+	res = httptest.NewRecorder()
+	api.ServeHTTP(res, req)
+	if res.Code != 200 {
+		t.Fatal("expecting 200")
+	}
+	var payload ClosureResponse
+	err := json.NewDecoder(res.Body).Decode(&payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = "[{ui db true} {db ui false} {tools service false}]"
+	if fmt.Sprint(payload.Reachable) != expected || payload.Closure != nil {
+		t.Fatal("expecting", expected, "got", payload.Reachable)
+	}
+}
+
+func TestApi_ServeHTTP_Policy(t *testing.T) {
+	body := []byte(`{
+		"policy": {"allow": [["ui", "service"], ["service", "db"]]},
+		"nodes": [
+			{"id": "page", "layer": "ui"},
+			{"id": "api", "layer": "service"},
+			{"id": "store", "layer": "db"}
+		],
+		"edges": [["page", "api"], ["api", "store"], ["page", "util"], ["util", "store"]]
+	}`)
+	logger := NewLogger()
+	logger.TestMode = true
+	api := NewApi(logger)
+
+	req := httptest.NewRequest("POST", "/policy", bytes.NewReader(body))
+	res := httptest.NewRecorder()
+	api.ServeHTTP(res, req)
+	if res.Code != 200 {
+		t.Fatal("expecting 200")
+	}
+	expected := `{"violations":[{"from":"page","to":"store","from_layer":"ui","to_layer":"db",` +
+		`"path":["page","util","store"]}]}` + "\n"
+	if res.Body.String() != expected {
+		t.Fatal("expecting", expected, "got", res.Body.String())
+	}
+
+	// sorting refuses graphs breaking the policy
+	req = httptest.NewRequest("POST", "/sort", bytes.NewReader(body))
+	res = httptest.NewRecorder()
+	api.ServeHTTP(res, req)
+	if res.Code != 422 {
+		t.Fatal("expecting 422")
+	}
+	apiErr := readApiError(t, res)
+	if apiErr.Code != "policy_violation" {
+		t.Fatal("expecting code policy_violation")
+	}
+}
+
+func TestApi_ServeHTTP_Explain(t *testing.T) {
+	logger := NewLogger()
+	logger.TestMode = true
+	api := NewApi(logger)
+
+	cases := []struct {
+		targets  string
+		expected string
+	}{
+		{`["ui", "db"]`, `{"relation":"before","path":["ui","service","db"]}`},
+		{`["db", "ui"]`, `{"relation":"after","path":["ui","service","db"]}`},
+		{`["ui", "tools"]`, `{"relation":"unordered","path":[]}`},
+	}
+	for _, c := range cases {
+		body := []byte(`{
+			"targets": ` + c.targets + `,
+			"edges": [["ui", "service"], ["service", "db"], ["tools", "db"]]
+		}`)
+		req := httptest.NewRequest("POST", "/explain", bytes.NewReader(body))
+		res := httptest.NewRecorder()
+		api.ServeHTTP(res, req)
+		if res.Code != 200 {
+			t.Fatal("expecting 200")
+		}
+		if res.Body.String() != c.expected+"\n" {
+			t.Fatal("expecting", c.expected, "got", res.Body.String())
+		}
+	}
+
+	body := []byte(`{"targets": ["ui"], "edges": [["ui", "db"]]}`)
+	req := httptest.NewRequest("POST", "/explain", bytes.NewReader(body))
+	res := httptest.NewRecorder()
+	api.ServeHTTP(res, req)
+	if res.Code != 400 {
+		t.Fatal("expecting 400")
+	}
+	apiErr := readApiError(t, res)
+	if apiErr.Code != "invalid_option" {
+		t.Fatal("expecting code invalid_option")
+	}
+
+	body = []byte(`{"targets": ["a", "b"], "edges": [["a", "b"], ["b", "c"], ["c", "b"]]}`)
+	req = httptest.NewRequest("POST", "/explain", bytes.NewReader(body))
+	res = httptest.NewRecorder()
+	api.ServeHTTP(res, req)
+	if res.Code != 422 {
+		t.Fatal("expecting 422")
+	}
+}
+
+func TestApi_ServeHTTP_Orders(t *testing.T) {
+	logger := NewLogger()
+	logger.TestMode = true
+	api := NewApi(logger)
+
+	body := []byte(`[["a", "b"], ["a", "c"], ["b", "d"], ["c", "d"]]`)
+	req := httptest.NewRequest("POST", "/orders?limit=1", bytes.NewReader(body))
+	res := httptest.NewRecorder()
+	api.ServeHTTP(res, req)
+	if res.Code != 200 {
+		t.Fatal("expecting 200")
+	}
+	var payload OrdersResponse
+	err := json.NewDecoder(res.Body).Decode(&payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(payload.Orders) != "[[a b c d]]" || payload.Count.Int64() != 2 || !payload.Exact {
+		t.Fatal("unexpected first page", payload)
+	}
+	if payload.Next == "" {
+		t.Fatal("expecting a cursor to the next page")
+	}
+
+	req = httptest.NewRequest("POST", "/orders?limit=1&cursor="+payload.Next, bytes.NewReader(body))
+	res = httptest.NewRecorder()
+	api.ServeHTTP(res, req)
+	if res.Code != 200 {
+		t.Fatal("expecting 200")
+	}
+	expected := `{"orders":[["a","c","b","d"]],"count":2,"exact":true}` + "\n"
+	if res.Body.String() != expected {
+		t.Fatal("expecting", expected, "got", res.Body.String())
+	}
+
+	for _, query := range []string{"limit=0", "limit=x", "cursor=bad"} {
+		req = httptest.NewRequest("POST", "/orders?"+query, bytes.NewReader(body))
+		res = httptest.NewRecorder()
+		api.ServeHTTP(res, req)
+		if res.Code != 400 {
+			t.Fatal("expecting 400 with", query)
+		}
+		apiErr := readApiError(t, res)
+		if apiErr.Code != "invalid_option" {
+			t.Fatal("expecting code invalid_option with", query)
+		}
+	}
+}
+
+func TestApi_ServeHTTP_Unique(t *testing.T) {
+	logger := NewLogger()
+	logger.TestMode = true
+	api := NewApi(logger)
+
+	body := []byte(`[["a", "b"], ["a", "c"]]`)
+	req := httptest.NewRequest("POST", "/unique", bytes.NewReader(body))
+	res := httptest.NewRecorder()
+	api.ServeHTTP(res, req)
+	if res.Code != 200 {
+		t.Fatal("expecting 200")
+	}
+	expected := `{"unique":false,"order":["a","b","c"],"incomparable":[["b","c"]]}` + "\n"
+	if res.Body.String() != expected {
+		t.Fatal("expecting", expected, "got", res.Body.String())
+	}
+
+	// sorting can require the order to be unique
+	req = httptest.NewRequest("POST", "/sort?require=unique", bytes.NewReader(body))
+	res = httptest.NewRecorder()
+	api.ServeHTTP(res, req)
+	if res.Code != 422 {
+		t.Fatal("expecting 422")
+	}
+	apiErr := readApiError(t, res)
+	if apiErr.Code != "ambiguous_order" {
+		t.Fatal("expecting code ambiguous_order")
+	}
+	pairs := apiErr.Details.(map[string]interface{})["incomparable"]
+	if fmt.Sprint(pairs) != "[[b c]]" {
+		t.Fatal("expecting b and c to be incomparable, got", pairs)
+	}
+
+	body = []byte(`[["a", "b"], ["b", "c"]]`)
+	req = httptest.NewRequest("POST", "/sort?require=unique", bytes.NewReader(body))
+	res = httptest.NewRecorder()
+	api.ServeHTTP(res, req)
+	if res.Code != 200 {
+		t.Fatal("expecting 200")
+	}
+	if res.Body.String() != `["a","b","c"]`+"\n" {
+		t.Fatal("expecting [a b c], got", res.Body.String())
+	}
+}
+
+func TestApi_ServeHTTP_SoftEdges(t *testing.T) {
+	logger := NewLogger()
+	logger.TestMode = true
+	api := NewApi(logger)
+
+	// soft edges are weighed after hard ones, whatever their position,
+	// and only between vertices present otherwise
+	body := []byte(`{"nodes": ["metrics"], "edges": [
+		{"from": "auth", "to": "core", "soft": true},
+		["core", "auth"],
+		{"from": "core", "to": "metrics", "soft": true},
+		{"from": "metrics", "to": "core", "soft": true},
+		{"from": "core", "to": "tracing", "soft": true}
+	]}`)
+	req := httptest.NewRequest("POST", "/sort", bytes.NewReader(body))
+	res := httptest.NewRecorder()
+	api.ServeHTTP(res, req)
+	if res.Code != 200 {
+		t.Fatal("expecting 200")
+	}
+	if res.Body.String() != `["core","auth","metrics"]`+"\n" {
+		t.Fatal("expecting [core auth metrics], got", res.Body.String())
+	}
+	ignored := res.Header().Get("X-Ignored-Edges")
+	if ignored != `[["auth","core"],["metrics","core"]]` {
+		t.Fatal("expecting ignored soft edges in a header, got", ignored)
+	}
+
+	req = httptest.NewRequest("POST", "/sort?format=levels", bytes.NewReader(body))
+	res = httptest.NewRecorder()
+	api.ServeHTTP(res, req)
+	if res.Code != 200 {
+		t.Fatal("expecting 200")
+	}
+	var payload SortResponse
+	err := json.NewDecoder(res.Body).Decode(&payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "[[auth core] [metrics core]]"
+	if fmt.Sprint(payload.Ignored) != expected {
+		t.Fatal("expecting", expected, "got", payload.Ignored)
+	}
+
+	body = []byte(`{"edges": [["a", "b"], {"from": "b", "to": "c", "soft": true}]}`)
+	req = httptest.NewRequest("POST", "/sort", bytes.NewReader(body))
+	res = httptest.NewRecorder()
+	api.ServeHTTP(res, req)
+	if res.Code != 200 {
+		t.Fatal("expecting 200")
+	}
+	if res.Header().Get("X-Ignored-Edges") != "" {
+		t.Fatal("expecting no header without ignored soft edges")
+	}
+}
+
+func TestApi_ServeHTTP_Pins(t *testing.T) {
+	logger := NewLogger()
+	logger.TestMode = true
+	api := NewApi(logger)
+
+	body := []byte(`{
+		"nodes": [{"id": "bootstrap", "pin": "first"}, {"id": "cleanup", "pin": "last"}],
+		"edges": [["schema", "users"], ["cleanup", "vacuum"]]
+	}`)
+	req := httptest.NewRequest("POST", "/sort", bytes.NewReader(body))
+	res := httptest.NewRecorder()
+	api.ServeHTTP(res, req)
+	if res.Code != 422 {
+		t.Fatal("expecting 422")
+	}
+	apiErr := readApiError(t, res)
+	if apiErr.Code != "pin_conflict" {
+		t.Fatal("expecting code pin_conflict")
+	}
+	expected := "can't pin cleanup last: vacuum depends on it and isn't pinned last"
+	if apiErr.Message != expected {
+		t.Fatal("expecting", expected, "got", apiErr.Message)
+	}
+
+	body = []byte(`{
+		"nodes": [{"id": "bootstrap", "pin": "first"}, {"id": "cleanup", "pin": "last"}],
+		"edges": [["schema", "users"], ["cleanup", "bootstrap"]]
+	}`)
+	req = httptest.NewRequest("POST", "/sort", bytes.NewReader(body))
+	res = httptest.NewRecorder()
+	api.ServeHTTP(res, req)
+	if res.Code != 422 {
+		t.Fatal("expecting 422")
+	}
+
+	body = []byte(`{
+		"nodes": [{"id": "bootstrap", "pin": "first"}, {"id": "cleanup", "pin": "last"}],
+		"edges": [["schema", "users"], ["users", "cleanup"]]
+	}`)
+	req = httptest.NewRequest("POST", "/sort", bytes.NewReader(body))
+	res = httptest.NewRecorder()
+	api.ServeHTTP(res, req)
+	if res.Code != 200 {
+		t.Fatal("expecting 200")
+	}
+	if res.Body.String() != `["bootstrap","schema","users","cleanup"]`+"\n" {
+		t.Fatal("expecting [bootstrap schema users cleanup], got", res.Body.String())
+	}
+
+	req = httptest.NewRequest("POST", "/sort?mode=priority", bytes.NewReader(body))
+	res = httptest.NewRecorder()
+	api.ServeHTTP(res, req)
+	if res.Code != 400 {
+		t.Fatal("expecting 400")
+	}
+}
+
+func TestApi_ServeHTTP_Stable(t *testing.T) {
+	logger := NewLogger()
+	logger.TestMode = true
+	api := NewApi(logger)
+
+	body := []byte(`{
+		"previous": ["web", "api", "cache", "db"],
+		"edges": [["web", "api"], ["db", "api"]],
+		"nodes": ["cache"]
+	}`)
+	req := httptest.NewRequest("POST", "/sort?mode=stable", bytes.NewReader(body))
+	res := httptest.NewRecorder()
+	api.ServeHTTP(res, req)
+	if res.Code != 200 {
+		t.Fatal("expecting 200")
+	}
+	expected := `{"order":["web","cache","db","api"],"moved":["api"]}` + "\n"
+	if res.Body.String() != expected {
+		t.Fatal("expecting", expected, "got", res.Body.String())
+	}
+
+	body = []byte(`[["web", "api"]]`)
+	req = httptest.NewRequest("POST", "/sort?mode=stable", bytes.NewReader(body))
+	res = httptest.NewRecorder()
+	api.ServeHTTP(res, req)
+	if res.Code != 400 {
+		t.Fatal("expecting 400")
+	}
+	apiErr := readApiError(t, res)
+	if apiErr.Code != "invalid_option" {
+		t.Fatal("expecting code invalid_option")
+	}
+}
+
+func TestApi_ServeHTTP_Only(t *testing.T) {
+	logger := NewLogger()
+	logger.TestMode = true
+	api := NewApi(logger)
+
+	// auth sorts before api through lib, which is filtered out
+	body := []byte(`{
+		"only": ["api", "auth", "web"],
+		"edges": [["web", "api"], ["auth", "lib"], ["lib", "api"], ["cache", "web"]]
+	}`)
+	req := httptest.NewRequest("POST", "/sort?format=levels", bytes.NewReader(body))
+	res := httptest.NewRecorder()
+	api.ServeHTTP(res, req)
+	if res.Code != 200 {
+		t.Fatal("expecting 200")
+	}
+	var payload SortResponse
+	err := json.NewDecoder(res.Body).Decode(&payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(payload.Levels) != "[[auth web] [api]]" {
+		t.Fatal("expecting [[auth web] [api]], got", payload.Levels)
+	}
+
+	// cycles among vertices left out still make the graph unsortable
+	body = []byte(`{"only": ["a"], "edges": [["a", "b"], ["b", "c"], ["c", "b"]]}`)
+	req = httptest.NewRequest("POST", "/sort", bytes.NewReader(body))
+	res = httptest.NewRecorder()
+	api.ServeHTTP(res, req)
+	if res.Code != 422 {
+		t.Fatal("expecting 422")
+	}
+	apiErr := readApiError(t, res)
+	if apiErr.Code != "cycle_detected" {
+		t.Fatal("expecting code cycle_detected")
+	}
+
+	req = httptest.NewRequest("POST", "/sort?mode=condense", bytes.NewReader(body))
+	res = httptest.NewRecorder()
+	api.ServeHTTP(res, req)
+	if res.Code != 200 {
+		t.Fatal("expecting 200 in condense mode")
+	}
+
+	body = []byte(`{"only": ["api", "missing"], "edges": [["web", "api"]]}`)
+	req = httptest.NewRequest("POST", "/sort", bytes.NewReader(body))
+	res = httptest.NewRecorder()
+	api.ServeHTTP(res, req)
+	if res.Code != 400 {
+		t.Fatal("expecting 400")
+	}
+	apiErr = readApiError(t, res)
+	if apiErr.Code != "unknown_vertex" {
+		t.Fatal("expecting code unknown_vertex")
+	}
+}
+
+func TestApi_ServeHTTP_Reverse(t *testing.T) {
+	logger := NewLogger()
+	logger.TestMode = true
+	api := NewApi(logger)
+
+	body := []byte(`[["a", "b"], ["a", "c"], ["c", "d"]]`)
+	cases := []struct {
+		query    string
+		expected string
+	}{
+		{"reverse=true", "[[d] [b c] [a]]"},
+		{"mode=dependents", "[[b d] [c] [a]]"},
+		{"mode=dependents&reverse=true", "[[a] [c] [b d]]"},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest("POST", "/sort?format=levels&"+c.query, bytes.NewReader(body))
+		res := httptest.NewRecorder()
+		api.ServeHTTP(res, req)
+		if res.Code != 200 {
+			t.Fatal("expecting 200 with", c.query)
+		}
+		var payload SortResponse
+		err := json.NewDecoder(res.Body).Decode(&payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprint(payload.Levels) != c.expected {
+			t.Fatal("expecting", c.expected, "with", c.query, "got", payload.Levels)
+		}
+	}
+
+	req := httptest.NewRequest("POST", "/sort?mode=dependents", bytes.NewReader(body))
+	res := httptest.NewRecorder()
+	api.ServeHTTP(res, req)
+	if res.Body.String() != `["b","d","c","a"]`+"\n" {
+		t.Fatal("expecting [b d c a], got", res.Body.String())
+	}
+
+	for _, query := range []string{"reverse=maybe", "mode=priority&reverse=true"} {
+		req = httptest.NewRequest("POST", "/sort?"+query, bytes.NewReader(body))
+		res = httptest.NewRecorder()
+		api.ServeHTTP(res, req)
+		if res.Code != 400 {
+			t.Fatal("expecting 400 with", query)
+		}
+	}
+}
+
+func TestApi_ServeHTTP_Feedback(t *testing.T) {
+	logger := NewLogger()
+	logger.TestMode = true
+	api := NewApi(logger)
+
+	body := []byte(`[["a", "b"], ["b", "c"], ["c", "a"], ["c", "d"], ["d", "b"]]`)
+	req := httptest.NewRequest("POST", "/feedback", bytes.NewReader(body))
+	res := httptest.NewRecorder()
+	api.ServeHTTP(res, req)
+	if res.Code != 200 {
+		t.Fatal("expecting 200")
+	}
+	expected := `{"edges":[["b","c"]],"exact":true}` + "\n"
+	if res.Body.String() != expected {
+		t.Fatal("expecting", expected, "got", res.Body.String())
+	}
+
+	req = httptest.NewRequest("POST", "/feedback?sort=true", bytes.NewReader(body))
+	res = httptest.NewRecorder()
+	api.ServeHTTP(res, req)
+	if res.Code != 200 {
+		t.Fatal("expecting 200")
+	}
+	expected = `{"edges":[["b","c"]],"exact":true,"order":["c","a","d","b"]}` + "\n"
+	if res.Body.String() != expected {
+		t.Fatal("expecting", expected, "got", res.Body.String())
+	}
+}
+
+func TestApi_ServeHTTP_404(t *testing.T) {
+	req := httptest.NewRequest("GET", "/does-not-exist", nil)
+	res := httptest.NewRecorder()
+
+	logger := NewLogger()
+	logger.TestMode = true
+	api := NewApi(logger)
+	api.ServeHTTP(res, req)
+	if res.Code != 404 {
+		t.Fatal("expecting 404")
+	}
+	apiErr := readApiError(t, res)
+	if apiErr.Code != "not_found" {
+		t.Fatal("expecting code not_found")
+	}
+	expected := "not found"
+	if apiErr.Message != expected {
+		t.Fatal("expecting msg", expected)
 	}
 }
 
@@ -0,0 +1,55 @@
+package lib
+
+// TransitiveReduction removes every edge implied by a longer path,
+// returning the removed edges ordered by their endpoints' topological order.
+func (g *Graph[T]) TransitiveReduction() ([]Edge[T], error) {
+	order, err := g.Order()
+	if err != nil {
+		return nil, err
+	}
+	position := map[T]int{}
+	for i, u := range order {
+		position[u] = i
+	}
+	byPosition := func(a, b T) bool {
+		return position[a] < position[b]
+	}
+
+	// walking up from the sinks, an edge u->v is redundant when v is
+	// already reachable through a successor of u that sorts before v
+	reachable := map[T]*Set[T]{}
+	redundant := map[T][]T{}
+	for i := len(order) - 1; i >= 0; i-- {
+		u := order[i]
+		reachable[u] = NewSet[T]()
+		for _, v := range g.AdjList[u].Sorted(byPosition) {
+			if reachable[u].Has(v) {
+				redundant[u] = append(redundant[u], v)
+				continue
+			}
+			reachable[u].Add(v)
+			for w := range reachable[v].Map {
+				reachable[u].Add(w)
+			}
+		}
+	}
+
+	var removed []Edge[T]
+	for _, u := range order {
+		for _, v := range redundant[u] {
+			g.RemoveEdge(u, v)
+			removed = append(removed, Edge[T]{u, v})
+		}
+	}
+	return removed, nil
+}
+
+// TransitiveClosure returns, for every vertex, the set of vertices reachable
+// from it. A vertex only reaches itself when it sits on a cycle.
+func (g *Graph[T]) TransitiveClosure() map[T]*Set[T] {
+	closure := map[T]*Set[T]{}
+	for u := range g.Vertices.Map {
+		closure[u] = g.Descendants(u)
+	}
+	return closure
+}
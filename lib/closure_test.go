@@ -0,0 +1,68 @@
+package lib
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestGraph_TransitiveReduction(t *testing.T) {
+	g := NewGraph[string]()
+	g.AddEdge("a", "b")
+	g.AddEdge("b", "c")
+	g.AddEdge("a", "c")
+	g.AddEdge("c", "d")
+	g.AddEdge("a", "d")
+	g.AddEdge("b", "d")
+	g.AddEdge("e", "d")
+	g.SetEdgeAttrs("a", "c", Attrs{"kind": "build"})
+
+	removed, err := g.TransitiveReduction()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "[{a c} {a d} {b d}]"
+	if fmt.Sprint(removed) != expected {
+		t.Fatal("expecting", expected, "got", removed)
+	}
+	if g.AdjList["a"].Size != 1 || g.AdjList["b"].Size != 1 || !g.AdjList["e"].Has("d") {
+		t.Fatal("expecting only a->b, b->c, c->d and e->d to remain")
+	}
+	if g.Indegree["d"] != 2 || g.RevList["d"].Size != 2 {
+		t.Fatal("expecting c and e to remain as predecessors of d")
+	}
+	if g.EdgeAttrs[Edge[string]{"a", "c"}] != nil {
+		t.Fatal("expecting attrs of removed edge to be dropped")
+	}
+
+	g = NewGraph[string]()
+	g.AddEdge("a", "b")
+	g.AddEdge("b", "a")
+	_, err = g.TransitiveReduction()
+	if _, ok := err.(*CycleError[string]); !ok {
+		t.Fatal("expecting cycle error")
+	}
+}
+
+func TestGraph_TransitiveClosure(t *testing.T) {
+	g := NewGraph[string]()
+	g.AddEdge("a", "b")
+	g.AddEdge("b", "c")
+	g.AddEdge("c", "b")
+	g.AddEdge("d", "a")
+	g.Less = func(a, b string) bool {
+		return a < b
+	}
+
+	closure := g.TransitiveClosure()
+	expected := map[string]string{
+		"a": "[b c]",
+		"b": "[b c]",
+		"c": "[b c]",
+		"d": "[a b c]",
+	}
+	for u, reachable := range expected {
+		if fmt.Sprint(closure[u].Sorted(g.less)) != reachable {
+			t.Fatal("expecting", reachable, "from", u, "got", closure[u].Sorted(g.less))
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package lib
+
+// Components returns the strongly connected components of the graph using
+// tarjan's algo. Components come out in reverse topological order,
+// each with its vertices ordered by the graph's tie-break.
+func (g *Graph[T]) Components() [][]T {
+	index := map[T]int{}
+	low := map[T]int{}
+	onStack := NewSet[T]()
+	var stack []T
+	var components [][]T
+
+	var connect func(u T)
+	connect = func(u T) {
+		index[u] = len(index)
+		low[u] = index[u]
+		stack = append(stack, u)
+		onStack.Add(u)
+
+		for _, v := range g.AdjList[u].Sorted(g.less) {
+			_, visited := index[v]
+			if !visited {
+				connect(v)
+				if low[v] < low[u] {
+					low[u] = low[v]
+				}
+			} else if onStack.Has(v) && index[v] < low[u] {
+				low[u] = index[v]
+			}
+		}
+
+		if low[u] != index[u] {
+			return
+		}
+		var component []T
+		for {
+			v := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack.Delete(v)
+			component = append(component, v)
+			if v == u {
+				break
+			}
+		}
+		g.SortVertices(component)
+		components = append(components, component)
+	}
+
+	for _, u := range g.Vertices.Sorted(g.less) {
+		_, visited := index[u]
+		if !visited {
+			connect(u)
+		}
+	}
+	return components
+}
+
+// NonTrivialComponents returns the components that contain a cycle,
+// either several vertices or a single vertex with an edge to itself.
+func (g *Graph[T]) NonTrivialComponents() [][]T {
+	var components [][]T
+	for _, component := range g.Components() {
+		u := component[0]
+		if len(component) > 1 || g.AdjList[u].Has(u) {
+			components = append(components, component)
+		}
+	}
+	return components
+}
+
+// Condense collapses every strongly connected component into a single vertex,
+// returning the resulting acyclic graph over component indices
+// along with the components themselves. Components are ordered
+// between each other by their first vertex.
+func (g *Graph[T]) Condense() (*Graph[int], [][]T) {
+	components := g.Components()
+	owner := map[T]int{}
+	condensed := NewGraph[int]()
+	condensed.Less = func(a, b int) bool {
+		return g.less(components[a][0], components[b][0])
+	}
+	for i, component := range components {
+		condensed.AddVertex(i)
+		for _, u := range component {
+			owner[u] = i
+		}
+	}
+
+	for u, adj := range g.AdjList {
+		for v := range adj.Map {
+			if owner[u] != owner[v] {
+				condensed.AddEdge(owner[u], owner[v])
+			}
+		}
+	}
+	return condensed, components
+}
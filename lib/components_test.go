@@ -0,0 +1,105 @@
+package lib
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestGraph_Components(t *testing.T) {
+	g := NewGraph[string]()
+	g.AddEdge("a", "b")
+	g.AddEdge("b", "c")
+	g.AddEdge("c", "a")
+	g.AddEdge("c", "d")
+	g.AddEdge("d", "e")
+	g.AddEdge("e", "d")
+	g.AddEdge("e", "f")
+
+	components := g.Components()
+	if len(components) != 3 {
+		t.Fatal("expecting 3 components")
+	}
+
+	// reverse topological order
+	var actual []string
+	for _, component := range components {
+		sort.Strings(component)
+		actual = append(actual, strings.Join(component, ""))
+	}
+	expected := "f de abc"
+	if strings.Join(actual, " ") != expected {
+		t.Fatal("expecting", expected, "got", actual)
+	}
+}
+
+func TestGraph_NonTrivialComponents(t *testing.T) {
+	g := NewGraph[string]()
+	g.AddEdge("a", "b")
+	g.AddEdge("b", "a")
+	g.AddEdge("b", "c")
+	g.AddEdge("c", "d")
+	g.AddEdge("d", "d")
+
+	components := g.NonTrivialComponents()
+	if len(components) != 2 {
+		t.Fatal("expecting 2 non-trivial components")
+	}
+	if len(components[0]) != 1 || components[0][0] != "d" {
+		t.Fatal("expecting self loop on d")
+	}
+	if len(components[1]) != 2 {
+		t.Fatal("expecting a and b")
+	}
+
+	g = NewGraph[string]()
+	g.AddEdge("a", "b")
+	if len(g.NonTrivialComponents()) != 0 {
+		t.Fatal("expecting no non-trivial components")
+	}
+}
+
+func TestGraph_Condense(t *testing.T) {
+	g := NewGraph[string]()
+	g.AddEdge("a", "b")
+	g.AddEdge("b", "a")
+	g.AddEdge("b", "c")
+	g.AddEdge("a", "c")
+	g.AddEdge("c", "d")
+	g.AddEdge("d", "c")
+
+	condensed, components := g.Condense()
+	if len(components) != 2 {
+		t.Fatal("expecting 2 components")
+	}
+	if condensed.Vertices.Size != 2 {
+		t.Fatal("expecting 2 condensed vertices")
+	}
+	if condensed.Indegree[0] != 1 || condensed.AdjList[1].Size != 1 {
+		t.Fatal("expecting a single edge between components")
+	}
+
+	var actual []string
+	for condensed.HasNextLevel() {
+		level, err := condensed.GetLevel()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, i := range level {
+			component := components[i]
+			sort.Strings(component)
+			actual = append(actual, strings.Join(component, ""))
+		}
+	}
+	if strings.Join(actual, " ") != "ab cd" {
+		t.Fatal("expecting ab before cd, got", actual)
+	}
+
+	g = NewGraph[string]()
+	g.AddEdge("a", "b")
+	g.AddEdge("b", "a")
+	condensed, _ = g.Condense()
+	if condensed.Vertices.Size != 1 || condensed.Sources.Size != 1 {
+		t.Fatal("expecting single source vertex")
+	}
+}
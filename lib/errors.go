@@ -0,0 +1,156 @@
+package lib
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+var ErrEmptyGraph = errors.New("seeing empty graph")
+var ErrNotFound = errors.New("not found")
+
+type MethodError struct {
+	Method string
+	Path   string
+	Allow  string
+}
+
+func (e *MethodError) Error() string {
+	return "unsupported method for " + e.Path
+}
+
+type OptionError struct {
+	Name   string
+	Value  string
+	Reason string
+}
+
+func (e *OptionError) Error() string {
+	msg := fmt.Sprintf("unsupported %s %q", e.Name, e.Value)
+	if e.Reason != "" {
+		msg += ": " + e.Reason
+	}
+	return msg
+}
+
+type UnknownVertexError struct {
+	Option   string
+	Vertices []string
+}
+
+func (e *UnknownVertexError) Error() string {
+	return fmt.Sprintf("seeing %d unknown vertices in %s", len(e.Vertices), e.Option)
+}
+
+type DecodeError struct {
+	Err error
+}
+
+func (e *DecodeError) Error() string {
+	return "error decoding edges input"
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// ApiError is the json envelope written for every failed request.
+type ApiError struct {
+	Status  int         `json:"-"`
+	Code    string      `json:"code"`
+	Message string      `json:"message"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+func (e *ApiError) Error() string {
+	return e.Message
+}
+
+// NewApiError maps errors raised while handling a request
+// onto a status code and a machine-readable error code.
+func NewApiError(err error) *ApiError {
+	var apiErr *ApiError
+	var methodErr *MethodError
+	var optionErr *OptionError
+	var maxBytesErr *http.MaxBytesError
+	var decodeErr *DecodeError
+	var validationErr *ValidationError
+	var unknownErr *UnknownVertexError
+	var cycleErr *CycleError[string]
+	var policyErr *PolicyError[string]
+	var ambiguousErr *AmbiguousOrderError[string]
+	var pinErr *PinError[string]
+	switch {
+	case errors.As(err, &apiErr):
+		return apiErr
+	case errors.As(err, &methodErr):
+		return &ApiError{http.StatusMethodNotAllowed, "method_not_allowed", err.Error(), map[string]interface{}{
+			"method": methodErr.Method,
+			"allow":  methodErr.Allow,
+		}}
+	case errors.As(err, &optionErr):
+		return &ApiError{http.StatusBadRequest, "invalid_option", err.Error(), map[string]interface{}{
+			"option": optionErr.Name,
+			"value":  optionErr.Value,
+		}}
+	case errors.As(err, &maxBytesErr):
+		return &ApiError{http.StatusRequestEntityTooLarge, "body_too_large", "request body too large", map[string]interface{}{
+			"limit": maxBytesErr.Limit,
+		}}
+	case errors.As(err, &decodeErr):
+		return &ApiError{http.StatusBadRequest, "invalid_json", err.Error(), decodeDetails(decodeErr.Err)}
+	case errors.As(err, &validationErr):
+		details := map[string]interface{}{}
+		if len(validationErr.Nodes) > 0 {
+			details["nodes"] = validationErr.Nodes
+		}
+		if len(validationErr.Edges) > 0 {
+			details["edges"] = validationErr.Edges
+		}
+		return &ApiError{http.StatusBadRequest, "invalid_input", err.Error(), details}
+	case errors.As(err, &unknownErr):
+		return &ApiError{http.StatusBadRequest, "unknown_vertex", err.Error(), map[string]interface{}{
+			"option":   unknownErr.Option,
+			"vertices": unknownErr.Vertices,
+		}}
+	case errors.As(err, &cycleErr):
+		return &ApiError{http.StatusUnprocessableEntity, "cycle_detected", err.Error(), map[string]interface{}{
+			"cycle": cycleErr.Cycle,
+		}}
+	case errors.As(err, &policyErr):
+		return &ApiError{http.StatusUnprocessableEntity, "policy_violation", err.Error(), map[string]interface{}{
+			"violations": policyErr.Violations,
+		}}
+	case errors.As(err, &ambiguousErr):
+		return &ApiError{http.StatusUnprocessableEntity, "ambiguous_order", err.Error(), map[string]interface{}{
+			"incomparable": ambiguousErr.Pairs,
+		}}
+	case errors.As(err, &pinErr):
+		return &ApiError{http.StatusUnprocessableEntity, "pin_conflict", err.Error(), map[string]interface{}{
+			"vertex": pinErr.Vertex,
+			"pin":    pinErr.Pin,
+			"path":   pinErr.Path,
+		}}
+	case errors.Is(err, ErrEmptyGraph):
+		return &ApiError{http.StatusBadRequest, "empty_graph", err.Error(), nil}
+	case errors.Is(err, ErrNotFound):
+		return &ApiError{http.StatusNotFound, "not_found", err.Error(), nil}
+	default:
+		return &ApiError{http.StatusInternalServerError, "internal_error", err.Error(), nil}
+	}
+}
+
+// decodeDetails reports where in the body json decoding failed, when known.
+func decodeDetails(err error) interface{} {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		return map[string]interface{}{"offset": syntaxErr.Offset}
+	case errors.As(err, &typeErr):
+		return map[string]interface{}{"offset": typeErr.Offset}
+	default:
+		return nil
+	}
+}
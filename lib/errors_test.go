@@ -0,0 +1,52 @@
+package lib
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestNewApiError(t *testing.T) {
+	var syntaxErr error = json.Unmarshal([]byte("{bad"), new([][]string))
+	cases := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{&MethodError{"GET", "/sort", "POST"}, 405, "method_not_allowed"},
+		{&OptionError{Name: "mode", Value: "bogus"}, 400, "invalid_option"},
+		{&DecodeError{syntaxErr}, 400, "invalid_json"},
+		{&ValidationError{Edges: []EdgeError{{0, "vertex 0 is empty"}}}, 400, "invalid_input"},
+		{&UnknownVertexError{"targets", []string{"x"}}, 400, "unknown_vertex"},
+		{&CycleError[string]{[]string{"a", "b", "a"}}, 422, "cycle_detected"},
+		{&PolicyError[string]{}, 422, "policy_violation"},
+		{&AmbiguousOrderError[string]{}, 422, "ambiguous_order"},
+		{&PinError[string]{}, 422, "pin_conflict"},
+		{fmt.Errorf("wrapped: %w", ErrEmptyGraph), 400, "empty_graph"},
+		{ErrNotFound, 404, "not_found"},
+		{errors.New("boom"), 500, "internal_error"},
+	}
+	for _, c := range cases {
+		apiErr := NewApiError(c.err)
+		if apiErr.Status != c.status {
+			t.Fatal("expecting status", c.status, "for", c.err)
+		}
+		if apiErr.Code != c.code {
+			t.Fatal("expecting code", c.code, "for", c.err)
+		}
+	}
+
+	apiErr := NewApiError(&DecodeError{syntaxErr})
+	if apiErr.Details.(map[string]interface{})["offset"] != int64(2) {
+		t.Fatal("expecting offset of syntax error")
+	}
+}
+
+func TestOptionError_Error(t *testing.T) {
+	err := &OptionError{Name: "format", Value: "levels", Reason: "not supported in condense mode"}
+	expected := `unsupported format "levels": not supported in condense mode`
+	if err.Error() != expected {
+		t.Fatal("expecting", expected)
+	}
+}
@@ -0,0 +1,177 @@
+package lib
+
+import (
+	"math/bits"
+	"sort"
+)
+
+// MaxExactFeedback is the size of the largest cycle-bearing component
+// searched exhaustively by FeedbackEdges.
+const MaxExactFeedback = 16
+
+// FeedbackEdges returns a small set of edges whose removal leaves the graph
+// acyclic, and whether that set is known to be the smallest possible.
+// Each component containing a cycle is laid out in the order with the fewest
+// backward edges, found exactly up to MaxExactFeedback vertices and with
+// eades' greedy heuristic beyond, and its backward edges are the ones returned.
+func (g *Graph[T]) FeedbackEdges() ([]Edge[T], bool) {
+	var edges []Edge[T]
+	exact := true
+	for _, component := range g.NonTrivialComponents() {
+		for _, u := range component {
+			if g.AdjList[u].Has(u) {
+				edges = append(edges, Edge[T]{u, u})
+			}
+		}
+		if len(component) == 1 {
+			continue
+		}
+
+		var order []T
+		if len(component) <= MaxExactFeedback {
+			order = g.exactFeedbackOrder(component)
+		} else {
+			order = g.greedyFeedbackOrder(component)
+			exact = false
+		}
+		position := map[T]int{}
+		for i, u := range order {
+			position[u] = i
+		}
+		for _, u := range order {
+			for _, v := range g.AdjList[u].Sorted(g.less) {
+				i, inside := position[v]
+				if inside && i < position[u] {
+					edges = append(edges, Edge[T]{u, v})
+				}
+			}
+		}
+	}
+
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].From != edges[j].From {
+			return g.less(edges[i].From, edges[j].From)
+		}
+		return g.less(edges[i].To, edges[j].To)
+	})
+	return edges, exact
+}
+
+// exactFeedbackOrder finds the order of component with the fewest backward
+// edges, building up the best order for every subset of its vertices.
+func (g *Graph[T]) exactFeedbackOrder(component []T) []T {
+	n := len(component)
+	index := map[T]int{}
+	for i, u := range component {
+		index[u] = i
+	}
+	out := make([]uint32, n)
+	for i, u := range component {
+		for v := range g.AdjList[u].Map {
+			j, inside := index[v]
+			if inside && j != i {
+				out[i] |= 1 << j
+			}
+		}
+	}
+
+	// placing u after the vertices of mask turns its edges into mask backward
+	cost := make([]int, 1<<n)
+	last := make([]int, 1<<n)
+	for mask := 1; mask < len(cost); mask++ {
+		cost[mask] = -1
+	}
+	for mask := 0; mask < len(cost); mask++ {
+		for u := 0; u < n; u++ {
+			if mask&(1<<u) != 0 {
+				continue
+			}
+			next := mask | 1<<u
+			c := cost[mask] + bits.OnesCount32(out[u]&uint32(mask))
+			if cost[next] < 0 || c < cost[next] {
+				cost[next] = c
+				last[next] = u
+			}
+		}
+	}
+
+	order := make([]T, n)
+	mask := len(cost) - 1
+	for i := n - 1; i >= 0; i-- {
+		order[i] = component[last[mask]]
+		mask &^= 1 << last[mask]
+	}
+	return order
+}
+
+// greedyFeedbackOrder lays out component with eades' heuristic, moving sinks
+// to the back and sources to the front, and otherwise the vertex with the
+// most outgoing over incoming edges to the front.
+func (g *Graph[T]) greedyFeedbackOrder(component []T) []T {
+	remaining := NewSet[T]()
+	for _, u := range component {
+		remaining.Add(u)
+	}
+	in := map[T]int{}
+	out := map[T]int{}
+	for _, u := range component {
+		for v := range g.AdjList[u].Map {
+			if v != u && remaining.Has(v) {
+				out[u]++
+				in[v]++
+			}
+		}
+	}
+
+	var front, back []T
+	candidates := append([]T{}, component...)
+	for len(candidates) > 0 {
+		pick := -1
+		for i, u := range candidates {
+			if out[u] == 0 {
+				pick = i
+				break
+			}
+		}
+		toBack := pick >= 0
+		for i, u := range candidates {
+			if pick < 0 && in[u] == 0 {
+				pick = i
+			}
+		}
+		if pick < 0 {
+			pick = 0
+			for i, u := range candidates {
+				best := candidates[pick]
+				if out[u]-in[u] > out[best]-in[best] {
+					pick = i
+				}
+			}
+		}
+
+		u := candidates[pick]
+		candidates = append(candidates[:pick], candidates[pick+1:]...)
+		remaining.Delete(u)
+		if toBack {
+			back = append(back, u)
+		} else {
+			front = append(front, u)
+		}
+		for v := range g.AdjList[u].Map {
+			if remaining.Has(v) {
+				in[v]--
+			}
+		}
+		for v := range g.RevList[u].Map {
+			if remaining.Has(v) {
+				out[v]--
+			}
+		}
+	}
+
+	// sinks were taken from the back
+	for i := len(back) - 1; i >= 0; i-- {
+		front = append(front, back[i])
+	}
+	return front
+}
@@ -0,0 +1,70 @@
+package lib
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestGraph_FeedbackEdges(t *testing.T) {
+	g := NewGraph[string]()
+	g.AddEdge("a", "b")
+	g.AddEdge("b", "c")
+	g.AddEdge("c", "a")
+	g.AddEdge("c", "d")
+	g.AddEdge("d", "b")
+	g.AddEdge("e", "e")
+	g.AddEdge("d", "f")
+	g.Less = func(a, b string) bool {
+		return a < b
+	}
+
+	// b->c sits on both cycles through b
+	edges, exact := g.FeedbackEdges()
+	expected := "[{b c} {e e}]"
+	if fmt.Sprint(edges) != expected || !exact {
+		t.Fatal("expecting", expected, "got", edges, exact)
+	}
+	for _, edge := range edges {
+		g.RemoveEdge(edge.From, edge.To)
+	}
+	_, err := g.Order()
+	if err != nil {
+		t.Fatal("expecting an acyclic graph once edges are removed")
+	}
+
+	g = NewGraph[string]()
+	g.AddEdge("a", "b")
+	edges, exact = g.FeedbackEdges()
+	if len(edges) != 0 || !exact {
+		t.Fatal("expecting no edges on an acyclic graph")
+	}
+}
+
+func TestGraph_FeedbackEdges_Greedy(t *testing.T) {
+	// a ring too large to search exhaustively, with a shortcut making a
+	// smaller ring that shares the edge 4->5 with it
+	g := NewGraph[int]()
+	n := MaxExactFeedback * 2
+	for i := 0; i < n; i++ {
+		g.AddEdge(i, (i+1)%n)
+	}
+	g.AddEdge(5, 2)
+	g.Less = func(a, b int) bool {
+		return a < b
+	}
+
+	edges, exact := g.FeedbackEdges()
+	if exact {
+		t.Fatal("expecting an inexact result")
+	}
+	if fmt.Sprint(edges) != "[{4 5}]" {
+		t.Fatal("expecting [{4 5}], got", edges)
+	}
+	for _, edge := range edges {
+		g.RemoveEdge(edge.From, edge.To)
+	}
+	_, err := g.Order()
+	if err != nil {
+		t.Fatal("expecting an acyclic graph once edges are removed")
+	}
+}
@@ -1,16 +1,51 @@
 package lib
 
 import (
+	"container/heap"
 	"errors"
+	"sort"
 )
 
+type CycleError[T comparable] struct {
+	Cycle []T
+}
+
+func (e *CycleError[T]) Error() string {
+	return "cycle detected"
+}
+
+// Attrs holds arbitrary metadata attached to a vertex or an edge.
+type Attrs map[string]interface{}
+
+type Edge[T comparable] struct {
+	From T
+	To   T
+}
+
 type Graph[T comparable] struct {
 	Sources    *Set[T]
 	Vertices   *Set[T]
 	Indegree   map[T]int
 	AdjList    map[T]*Set[T]
+	RevList    map[T]*Set[T]
 	Undirected bool
 
+	// Less breaks ties between vertices that could be sorted in any order.
+	// When nil, vertices keep the order in which they were first seen.
+	Less func(a, b T) bool
+	Seen map[T]int
+
+	VertexAttrs map[T]Attrs
+	EdgeAttrs   map[Edge[T]]Attrs
+	Weights     map[T]float64
+	Priorities  map[T]float64
+	Layers      map[T]string
+	Pins        map[T]string
+	LevelPins   map[T]int
+
+	// IgnoredEdges are the soft edges left out for closing a cycle.
+	IgnoredEdges []Edge[T]
+
 	SortLevel     *Set[T]
 	SortDegrees   map[T]int
 	SortRemaining int
@@ -22,6 +57,15 @@ func NewGraph[T comparable]() *Graph[T] {
 	g.Vertices = NewSet[T]()
 	g.Indegree = map[T]int{}
 	g.AdjList = map[T]*Set[T]{}
+	g.RevList = map[T]*Set[T]{}
+	g.Seen = map[T]int{}
+	g.VertexAttrs = map[T]Attrs{}
+	g.EdgeAttrs = map[Edge[T]]Attrs{}
+	g.Weights = map[T]float64{}
+	g.Priorities = map[T]float64{}
+	g.Layers = map[T]string{}
+	g.Pins = map[T]string{}
+	g.LevelPins = map[T]int{}
 
 	g.SortLevel = NewSet[T]()
 	g.SortDegrees = map[T]int{}
@@ -38,18 +82,12 @@ func (g *Graph[T]) AddEdge(u, v T) {
 		g.Undirected = true
 	}
 
-	if !uExists {
-		g.Sources.Add(u)
-		g.Vertices.Add(u)
-		g.AdjList[u] = NewSet[T]()
-	}
-	if !vExists {
-		g.Vertices.Add(v)
-		g.AdjList[v] = NewSet[T]()
-	}
+	g.AddVertex(u)
+	g.AddVertex(v)
 
 	g.Indegree[v]++
 	g.AdjList[u].Add(v)
+	g.RevList[v].Add(u)
 	g.Sources.Delete(v)
 
 	g.SortLevel = g.Sources
@@ -57,6 +95,143 @@ func (g *Graph[T]) AddEdge(u, v T) {
 	g.SortRemaining = g.Vertices.Size
 }
 
+// RemoveEdge removes the edge u->v along with its metadata, if present.
+// The sort state is left alone, sorting again starts with ResetSort.
+func (g *Graph[T]) RemoveEdge(u, v T) {
+	if !g.Vertices.Has(u) || !g.AdjList[u].Has(v) {
+		return
+	}
+	g.AdjList[u].Delete(v)
+	g.RevList[v].Delete(u)
+	delete(g.EdgeAttrs, Edge[T]{u, v})
+
+	g.Indegree[v]--
+	if g.Indegree[v] == 0 {
+		delete(g.Indegree, v)
+		g.Sources.Add(v)
+	}
+}
+
+// AddSoftEdge adds the edge u->v when both vertices are already present,
+// unless v already reaches u, in which case the edge would close a cycle
+// and is recorded in IgnoredEdges instead.
+func (g *Graph[T]) AddSoftEdge(u, v T) bool {
+	if !g.Vertices.Has(u) || !g.Vertices.Has(v) {
+		return false
+	}
+	if u == v || g.Descendants(v).Has(u) {
+		g.IgnoredEdges = append(g.IgnoredEdges, Edge[T]{u, v})
+		return false
+	}
+	g.AddEdge(u, v)
+	return true
+}
+
+// AddVertex adds a vertex without any edges,
+// leaving it a source until an edge points to it.
+func (g *Graph[T]) AddVertex(u T) {
+	if g.Vertices.Has(u) {
+		return
+	}
+	g.Sources.Add(u)
+	g.Vertices.Add(u)
+	g.AdjList[u] = NewSet[T]()
+	g.RevList[u] = NewSet[T]()
+	g.Seen[u] = len(g.Seen)
+
+	g.SortLevel = g.Sources
+	g.SortRemaining = g.Vertices.Size
+}
+
+// SetVertexAttrs merges attrs into the metadata of vertex u, adding u if needed.
+func (g *Graph[T]) SetVertexAttrs(u T, attrs Attrs) {
+	g.AddVertex(u)
+	g.VertexAttrs[u] = mergeAttrs(g.VertexAttrs[u], attrs)
+}
+
+// SetEdgeAttrs merges attrs into the metadata of edge u->v, adding the edge if needed.
+func (g *Graph[T]) SetEdgeAttrs(u, v T, attrs Attrs) {
+	g.AddEdge(u, v)
+	key := Edge[T]{u, v}
+	g.EdgeAttrs[key] = mergeAttrs(g.EdgeAttrs[key], attrs)
+}
+
+// SetWeight sets the weight of vertex u, adding u if needed.
+func (g *Graph[T]) SetWeight(u T, weight float64) {
+	g.AddVertex(u)
+	g.Weights[u] = weight
+}
+
+// Weight returns the weight of vertex u, defaulting to 1 when unset.
+func (g *Graph[T]) Weight(u T) float64 {
+	weight, exists := g.Weights[u]
+	if !exists {
+		return 1
+	}
+	return weight
+}
+
+// SetPriority sets the priority of vertex u, adding u if needed.
+// Vertices without a priority have a priority of 0.
+func (g *Graph[T]) SetPriority(u T, priority float64) {
+	g.AddVertex(u)
+	g.Priorities[u] = priority
+}
+
+// Subgraph returns the graph induced by the given vertices, keeping
+// their edges, metadata and tie-break.
+func (g *Graph[T]) Subgraph(vertices *Set[T]) *Graph[T] {
+	sub := NewGraph[T]()
+	sub.Less = g.Less
+	for _, u := range g.Vertices.Sorted(func(a, b T) bool { return g.Seen[a] < g.Seen[b] }) {
+		if !vertices.Has(u) {
+			continue
+		}
+		sub.SetVertexAttrs(u, g.VertexAttrs[u])
+		weight, exists := g.Weights[u]
+		if exists {
+			sub.SetWeight(u, weight)
+		}
+		priority, exists := g.Priorities[u]
+		if exists {
+			sub.SetPriority(u, priority)
+		}
+		layer, exists := g.Layers[u]
+		if exists {
+			sub.SetLayer(u, layer)
+		}
+		pin, exists := g.Pins[u]
+		if exists {
+			sub.SetPin(u, pin)
+		}
+		level, exists := g.LevelPins[u]
+		if exists {
+			sub.SetLevelPin(u, level)
+		}
+	}
+	for u := range sub.Vertices.Map {
+		for v := range g.AdjList[u].Map {
+			if vertices.Has(v) {
+				sub.SetEdgeAttrs(u, v, g.EdgeAttrs[Edge[T]{u, v}])
+			}
+		}
+	}
+	return sub
+}
+
+func mergeAttrs(dst, src Attrs) Attrs {
+	if len(src) == 0 {
+		return dst
+	}
+	if dst == nil {
+		dst = Attrs{}
+	}
+	for k, v := range src {
+		dst[k] = v
+	}
+	return dst
+}
+
 func (g *Graph[T]) HasNextLevel() bool {
 	return g.SortRemaining > 0
 }
@@ -64,10 +239,10 @@ func (g *Graph[T]) HasNextLevel() bool {
 func (g *Graph[T]) GetLevel() ([]T, error) {
 	var level []T
 	nextLevel := NewSet[T]()
-	for u := range g.SortLevel.Iterator() {
+	for _, u := range g.SortLevel.Sorted(g.less) {
 		level = append(level, u)
 		g.SortRemaining--
-		for v := range g.AdjList[u].Iterator() {
+		for v := range g.AdjList[u].Map {
 			g.SortDegrees[v]--
 			if g.SortDegrees[v] == 0 {
 				nextLevel.Add(v)
@@ -77,16 +252,210 @@ func (g *Graph[T]) GetLevel() ([]T, error) {
 
 	g.SortLevel = nextLevel
 	if g.SortLevel.Size == 0 && g.SortRemaining > 0 {
-		return nil, errors.New("cycle detected")
+		return nil, &CycleError[T]{Cycle: g.FindCycle()}
 	} else {
 		return level, nil
 	}
 }
 
+// Levels runs the remainder of the sort, returning each level in order.
+func (g *Graph[T]) Levels() ([][]T, error) {
+	var levels [][]T
+	for g.HasNextLevel() {
+		level, err := g.GetLevel()
+		if err != nil {
+			return nil, err
+		}
+		levels = append(levels, level)
+	}
+	return levels, nil
+}
+
+// Order restarts the sort and returns every vertex in topological order.
+func (g *Graph[T]) Order() ([]T, error) {
+	g.ResetSort()
+	levels, err := g.Levels()
+	if err != nil {
+		return nil, err
+	}
+	return flatten(levels), nil
+}
+
+func flatten[T any](levels [][]T) []T {
+	var order []T
+	for _, level := range levels {
+		order = append(order, level...)
+	}
+	return order
+}
+
+// OrderBy restarts the sort and returns every vertex in topological order,
+// always picking the least ready vertex by less next.
+func (g *Graph[T]) OrderBy(less func(a, b T) bool) ([]T, error) {
+	g.ResetSort()
+	ready := &vertexHeap[T]{less: less}
+	for u := range g.Sources.Map {
+		heap.Push(ready, u)
+	}
+
+	var order []T
+	for ready.Len() > 0 {
+		u := heap.Pop(ready).(T)
+		order = append(order, u)
+		g.SortRemaining--
+		for v := range g.AdjList[u].Map {
+			g.SortDegrees[v]--
+			if g.SortDegrees[v] == 0 {
+				heap.Push(ready, v)
+			}
+		}
+	}
+
+	g.SortLevel = NewSet[T]()
+	if g.SortRemaining > 0 {
+		return nil, &CycleError[T]{Cycle: g.FindCycle()}
+	}
+	return order, nil
+}
+
+// PriorityOrder returns every vertex in topological order, picking
+// the ready vertex with the highest priority next.
+func (g *Graph[T]) PriorityOrder() ([]T, error) {
+	return g.OrderBy(func(a, b T) bool {
+		if g.Priorities[a] != g.Priorities[b] {
+			return g.Priorities[a] > g.Priorities[b]
+		}
+		return g.less(a, b)
+	})
+}
+
+// Reversed returns a copy of the graph with every edge pointing the other way,
+// keeping the tie-break and the order vertices were first seen in.
+func (g *Graph[T]) Reversed() *Graph[T] {
+	rev := NewGraph[T]()
+	rev.Less = g.Less
+	vertices := g.Vertices.Sorted(func(a, b T) bool { return g.Seen[a] < g.Seen[b] })
+	for _, u := range vertices {
+		rev.AddVertex(u)
+	}
+	for _, u := range vertices {
+		for v := range g.AdjList[u].Map {
+			rev.AddEdge(v, u)
+		}
+	}
+	return rev
+}
+
+// DependentsLevels returns levels sorted dependents first, from the vertices
+// nothing depends on down to the sources, each vertex in the first level
+// after all of its dependents, by sorting the reversed graph.
+func (g *Graph[T]) DependentsLevels() ([][]T, error) {
+	levels, err := g.Reversed().Levels()
+	var cycleErr *CycleError[T]
+	if errors.As(err, &cycleErr) {
+		// the cycle was found walking edges backwards
+		cycle := cycleErr.Cycle
+		for i, j := 0, len(cycle)-1; i < j; i, j = i+1, j-1 {
+			cycle[i], cycle[j] = cycle[j], cycle[i]
+		}
+	}
+	return levels, err
+}
+
+// FindCycle returns one cycle among the vertices left unsorted,
+// as an ordered list of vertices that starts and ends on the same vertex.
+func (g *Graph[T]) FindCycle() []T {
+	// every unsorted vertex still has an unsorted predecessor,
+	// so walking predecessors backwards must end up in a loop
+	pred := map[T]T{}
+	var start T
+	found := false
+	for _, u := range g.Vertices.Sorted(g.less) {
+		if g.SortDegrees[u] == 0 {
+			continue
+		}
+		for _, v := range g.AdjList[u].Sorted(g.less) {
+			if g.SortDegrees[v] == 0 {
+				continue
+			}
+			if !found {
+				start = v
+				found = true
+			}
+			pred[v] = u
+		}
+	}
+	if !found {
+		return nil
+	}
+
+	var walk []T
+	seen := map[T]int{}
+	u := start
+	for {
+		i, exists := seen[u]
+		if exists {
+			walk = append(walk[i:], u)
+			break
+		}
+		seen[u] = len(walk)
+		walk = append(walk, u)
+		u = pred[u]
+	}
+
+	// walk is in reverse edge direction
+	for i, j := 0, len(walk)-1; i < j; i, j = i+1, j-1 {
+		walk[i], walk[j] = walk[j], walk[i]
+	}
+
+	// start the loop from its first vertex in tie-break order
+	first := 0
+	for i := range walk[:len(walk)-1] {
+		if g.less(walk[i], walk[first]) {
+			first = i
+		}
+	}
+	var cycle []T
+	cycle = append(cycle, walk[first:len(walk)-1]...)
+	cycle = append(cycle, walk[:first]...)
+	return append(cycle, walk[first])
+}
+
+// SortVertices orders vertices in place using the graph's tie-break.
+func (g *Graph[T]) SortVertices(vertices []T) {
+	sort.Slice(vertices, func(i, j int) bool {
+		return g.less(vertices[i], vertices[j])
+	})
+}
+
+func (g *Graph[T]) less(a, b T) bool {
+	if g.Less != nil {
+		return g.Less(a, b)
+	}
+	return g.Seen[a] < g.Seen[b]
+}
+
 func (g *Graph[T]) ResetSort() {
 	g.SortRemaining = g.Vertices.Size
 	g.SortLevel = g.Sources
+	g.SortDegrees = map[T]int{}
 	for k, v := range g.Indegree {
 		g.SortDegrees[k] = v
 	}
 }
+
+type vertexHeap[T comparable] struct {
+	items []T
+	less  func(a, b T) bool
+}
+
+func (h *vertexHeap[T]) Len() int           { return len(h.items) }
+func (h *vertexHeap[T]) Less(i, j int) bool { return h.less(h.items[i], h.items[j]) }
+func (h *vertexHeap[T]) Swap(i, j int)      { h.items[i], h.items[j] = h.items[j], h.items[i] }
+func (h *vertexHeap[T]) Push(x interface{}) { h.items = append(h.items, x.(T)) }
+
+func (h *vertexHeap[T]) Pop() interface{} {
+	last := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return last
+}
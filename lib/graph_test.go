@@ -20,6 +20,9 @@ func TestNewGraph(t *testing.T) {
 	if g.AdjList == nil {
 		t.Fatal("expecting initialized adj list map")
 	}
+	if g.RevList == nil {
+		t.Fatal("expecting initialized reverse adj list map")
+	}
 	if g.Undirected {
 		t.Fatal("expecting undirected flag to be false")
 	}
@@ -76,11 +79,160 @@ func TestGraph_AddEdge(t *testing.T) {
 	if g.AdjList["d"].Size != 1 {
 		t.Fatal("expecting one neighbor for d")
 	}
+	if !g.RevList["c"].Has("b") || !g.RevList["c"].Has("d") || g.RevList["c"].Size != 2 {
+		t.Fatal("expecting b and d as predecessors of c")
+	}
+	if g.RevList["a"].Size != 0 {
+		t.Fatal("expecting no predecessors for a")
+	}
 	if g.Undirected {
 		t.Fatal("expecting directed graph")
 	}
 }
 
+func TestGraph_AddVertex(t *testing.T) {
+	g := NewGraph[string]()
+	g.AddVertex("a")
+	g.AddVertex("a")
+	g.AddEdge("b", "c")
+	g.AddVertex("c")
+	if g.Vertices.Size != 3 {
+		t.Fatal("expecting size of 3")
+	}
+	if !g.Sources.Has("a") || g.Sources.Has("c") {
+		t.Fatal("expecting a to be a source and c not")
+	}
+	if g.AdjList["a"].Size != 0 {
+		t.Fatal("expecting no neighbors for a")
+	}
+
+	levels, err := g.Levels()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(levels) != "[[a b] [c]]" {
+		t.Fatal("unexpected levels", levels)
+	}
+}
+
+func TestGraph_Attrs(t *testing.T) {
+	g := NewGraph[string]()
+	g.SetVertexAttrs("a", Attrs{"owner": "infra", "label": "A"})
+	g.SetVertexAttrs("a", Attrs{"label": "a"})
+	g.SetVertexAttrs("b", nil)
+	g.SetEdgeAttrs("a", "b", Attrs{"kind": "build"})
+	g.SetEdgeAttrs("b", "c", nil)
+
+	if g.Vertices.Size != 3 {
+		t.Fatal("expecting size of 3")
+	}
+	if fmt.Sprint(g.VertexAttrs["a"]) != "map[label:a owner:infra]" {
+		t.Fatal("expecting merged attrs on a", g.VertexAttrs["a"])
+	}
+	if g.VertexAttrs["b"] != nil {
+		t.Fatal("expecting no attrs on b")
+	}
+	if !g.AdjList["a"].Has("b") || g.EdgeAttrs[Edge[string]{"a", "b"}]["kind"] != "build" {
+		t.Fatal("expecting kind attr on a->b")
+	}
+	if !g.AdjList["b"].Has("c") {
+		t.Fatal("expecting b->c")
+	}
+}
+
+func TestGraph_Subgraph(t *testing.T) {
+	g := NewGraph[string]()
+	g.AddEdge("c", "b")
+	g.AddEdge("b", "a")
+	g.AddEdge("c", "a")
+	g.AddEdge("a", "d")
+	g.SetVertexAttrs("b", Attrs{"owner": "infra"})
+	g.SetEdgeAttrs("c", "a", Attrs{"kind": "build"})
+	g.SetWeight("a", 4)
+
+	set := NewSet[string]()
+	set.Add("a")
+	set.Add("b")
+	set.Add("c")
+	sub := g.Subgraph(set)
+	if sub.Vertices.Size != 3 || sub.Vertices.Has("d") {
+		t.Fatal("expecting a, b and c only")
+	}
+	if sub.AdjList["a"].Size != 0 || sub.AdjList["c"].Size != 2 {
+		t.Fatal("expecting induced edges only")
+	}
+	if sub.VertexAttrs["b"]["owner"] != "infra" || sub.EdgeAttrs[Edge[string]{"c", "a"}]["kind"] != "build" {
+		t.Fatal("expecting attrs to carry over")
+	}
+	if sub.Weight("a") != 4 || sub.Weight("b") != 1 {
+		t.Fatal("expecting weights to carry over")
+	}
+
+	// first seen order carries over as the tie-break
+	order, err := sub.Order()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(order, "") != "cba" {
+		t.Fatal("unexpected order", order)
+	}
+}
+
+func TestGraph_RemoveEdge(t *testing.T) {
+	g := NewGraph[string]()
+	g.AddEdge("a", "b")
+	g.AddEdge("c", "b")
+	g.SetEdgeAttrs("a", "b", Attrs{"kind": "build"})
+
+	g.RemoveEdge("a", "b")
+	g.RemoveEdge("a", "b")
+	g.RemoveEdge("x", "y")
+	if g.AdjList["a"].Has("b") || g.RevList["b"].Has("a") {
+		t.Fatal("expecting a->b removed")
+	}
+	if g.Indegree["b"] != 1 || g.Sources.Has("b") {
+		t.Fatal("expecting b to keep c as predecessor")
+	}
+	if g.EdgeAttrs[Edge[string]{"a", "b"}] != nil {
+		t.Fatal("expecting attrs of a->b dropped")
+	}
+
+	g.RemoveEdge("c", "b")
+	_, exists := g.Indegree["b"]
+	if exists || !g.Sources.Has("b") {
+		t.Fatal("expecting b to become a source")
+	}
+	if g.Vertices.Size != 3 {
+		t.Fatal("expecting vertices to remain")
+	}
+}
+
+func TestGraph_AddSoftEdge(t *testing.T) {
+	g := NewGraph[string]()
+	g.AddEdge("a", "b")
+	g.AddEdge("b", "c")
+
+	if !g.AddSoftEdge("a", "c") {
+		t.Fatal("expecting a->c to be added")
+	}
+	g.AddVertex("d")
+	if g.AddSoftEdge("c", "a") || g.AddSoftEdge("d", "d") {
+		t.Fatal("expecting edges closing a cycle to be ignored")
+	}
+	if g.AdjList["c"].Has("a") || g.AdjList["d"].Has("d") {
+		t.Fatal("expecting ignored edges left out")
+	}
+
+	// soft edges only hold between vertices that are present
+	if g.AddSoftEdge("a", "x") || g.Vertices.Has("x") {
+		t.Fatal("expecting x not to be added")
+	}
+	expected := "[{c a} {d d}]"
+	if fmt.Sprint(g.IgnoredEdges) != expected {
+		t.Fatal("expecting", expected, "got", g.IgnoredEdges)
+	}
+}
+
 func TestGraph_Undirected(t *testing.T) {
 	g := NewGraph[string]()
 	g.AddEdge("a", "b")
@@ -123,6 +275,46 @@ func TestGraph_TestCycle(t *testing.T) {
 	}
 }
 
+func TestGraph_FindCycle(t *testing.T) {
+	g := NewGraph[string]()
+	g.AddEdge("a", "b")
+	g.AddEdge("b", "c")
+	g.AddEdge("c", "d")
+	g.AddEdge("d", "b")
+	g.AddEdge("d", "e")
+	var err error
+	for g.HasNextLevel() && err == nil {
+		_, err = g.GetLevel()
+	}
+	cycleErr, ok := err.(*CycleError[string])
+	if !ok {
+		t.Fatal("expecting cycle error")
+	}
+	actual := strings.Join(cycleErr.Cycle, "")
+	if actual != "bcdb" {
+		t.Fatal("unexpected cycle", actual)
+	}
+
+	g = NewGraph[string]()
+	g.AddEdge("a", "b")
+	g.AddEdge("b", "a")
+	_, err = g.GetLevel()
+	cycleErr, ok = err.(*CycleError[string])
+	if !ok {
+		t.Fatal("expecting cycle error")
+	}
+	actual = strings.Join(cycleErr.Cycle, "")
+	if actual != "aba" {
+		t.Fatal("unexpected cycle", actual)
+	}
+
+	g = NewGraph[string]()
+	g.AddEdge("a", "b")
+	if g.FindCycle() != nil {
+		t.Fatal("expecting no cycle")
+	}
+}
+
 func TestGraph_HasNextLevel(t *testing.T) {
 	g := NewGraph[string]()
 	g.AddEdge("a", "b")
@@ -204,6 +396,165 @@ func TestGraph_NextLevel(t *testing.T) {
 	}
 }
 
+func TestGraph_Levels(t *testing.T) {
+	g := NewGraph[string]()
+	g.AddEdge("a", "c")
+	g.AddEdge("b", "c")
+	g.AddEdge("c", "d")
+	g.AddEdge("c", "e")
+
+	levels, err := g.Levels()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(levels) != "[[a b] [c] [d e]]" {
+		t.Fatal("unexpected levels", levels)
+	}
+	if g.HasNextLevel() {
+		t.Fatal("expecting sort to be complete")
+	}
+
+	g = NewGraph[string]()
+	g.AddEdge("a", "b")
+	g.AddEdge("b", "a")
+	_, err = g.Levels()
+	if err == nil {
+		t.Fatal("expecting cycle error")
+	}
+}
+
+func TestGraph_OrderBy(t *testing.T) {
+	g := NewGraph[string]()
+	g.AddEdge("a", "b")
+	g.AddEdge("c", "d")
+	g.AddEdge("b", "d")
+
+	// reverse lexicographic as long as edges allow it
+	order, err := g.OrderBy(func(a, b string) bool {
+		return a > b
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(order, "") != "cabd" {
+		t.Fatal("unexpected order", order)
+	}
+	if g.HasNextLevel() {
+		t.Fatal("expecting sort to be complete")
+	}
+
+	g.AddEdge("d", "a")
+	_, err = g.OrderBy(g.less)
+	cycleErr, ok := err.(*CycleError[string])
+	if !ok {
+		t.Fatal("expecting cycle error")
+	}
+	if strings.Join(cycleErr.Cycle, "") != "abda" {
+		t.Fatal("unexpected cycle", cycleErr.Cycle)
+	}
+}
+
+func TestGraph_PriorityOrder(t *testing.T) {
+	g := NewGraph[string]()
+	g.AddEdge("a", "b")
+	g.AddVertex("c")
+	g.AddVertex("d")
+	g.SetPriority("b", 20)
+	g.SetPriority("c", 10)
+	g.SetPriority("d", 1)
+
+	order, err := g.PriorityOrder()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(order, "") != "cdab" {
+		t.Fatal("unexpected order", order)
+	}
+}
+
+func TestGraph_TieBreak(t *testing.T) {
+	g := NewGraph[string]()
+	g.AddEdge("z", "b")
+	g.AddEdge("y", "b")
+	g.AddEdge("x", "b")
+
+	// first seen
+	level, err := g.GetLevel()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(level, "") != "zyx" {
+		t.Fatal("expecting first seen order, got", level)
+	}
+
+	// custom comparator
+	g.Less = func(a, b string) bool {
+		return a < b
+	}
+	g.ResetSort()
+	level, err = g.GetLevel()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(level, "") != "xyz" {
+		t.Fatal("expecting lexicographic order, got", level)
+	}
+
+	vertices := []string{"b", "z", "x"}
+	g.SortVertices(vertices)
+	if strings.Join(vertices, "") != "bxz" {
+		t.Fatal("expecting sorted vertices, got", vertices)
+	}
+}
+
+func TestGraph_Reversed(t *testing.T) {
+	g := NewGraph[string]()
+	g.AddEdge("b", "a")
+	g.AddEdge("b", "c")
+
+	rev := g.Reversed()
+	if !rev.AdjList["a"].Has("b") || !rev.AdjList["c"].Has("b") || rev.AdjList["b"].Size != 0 {
+		t.Fatal("expecting edges a->b and c->b")
+	}
+	order, err := rev.Order()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(order) != "[a c b]" {
+		t.Fatal("expecting first seen order [a c b], got", order)
+	}
+}
+
+func TestGraph_DependentsLevels(t *testing.T) {
+	g := NewGraph[string]()
+	g.AddEdge("a", "b")
+	g.AddEdge("a", "c")
+	g.AddEdge("c", "d")
+	g.Less = func(a, b string) bool {
+		return a < b
+	}
+
+	levels, err := g.DependentsLevels()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "[[b d] [c] [a]]"
+	if fmt.Sprint(levels) != expected {
+		t.Fatal("expecting", expected, "got", levels)
+	}
+
+	// cycles are reported along the edges of the graph
+	g.AddEdge("d", "a")
+	_, err = g.DependentsLevels()
+	cycleErr, ok := err.(*CycleError[string])
+	if !ok {
+		t.Fatal("expecting cycle error")
+	}
+	if fmt.Sprint(cycleErr.Cycle) != "[a c d a]" {
+		t.Fatal("expecting cycle [a c d a], got", cycleErr.Cycle)
+	}
+}
+
 func TestGraph_ResetSort(t *testing.T) {
 	g := NewGraph[string]()
 	g.AddEdge("a", "b")
@@ -232,3 +583,37 @@ func TestGraph_ResetSort(t *testing.T) {
 		t.Fatal("expecting a reset of sort indegree map on reset")
 	}
 }
+
+func TestGraph_ResetSort_AfterRemoveEdge(t *testing.T) {
+	g := NewGraph[string]()
+	g.AddEdge("a", "b")
+	g.AddEdge("b", "c")
+
+	// sorting after removing edges must not see the indegrees of the previous sort
+	_, err := g.Order()
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.RemoveEdge("b", "c")
+	order, err := g.Order()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(order) != "[a c b]" {
+		t.Fatal("expecting [a c b], got", order)
+	}
+	for u, degree := range g.SortDegrees {
+		if degree != 0 {
+			t.Fatal("expecting no sort indegree left, got", u, degree)
+		}
+	}
+
+	g.AddEdge("c", "d")
+	g.AddEdge("d", "e")
+	g.AddEdge("e", "d")
+	_, err = g.Order()
+	cycleErr, ok := err.(*CycleError[string])
+	if !ok || fmt.Sprint(cycleErr.Cycle) != "[d e d]" {
+		t.Fatal("expecting cycle [d e d], got", err)
+	}
+}
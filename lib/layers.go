@@ -0,0 +1,74 @@
+package lib
+
+import (
+	"fmt"
+)
+
+// LayerViolation is a dependency between vertices of two layers that the
+// policy doesn't allow. Path runs from one vertex to the other through
+// vertices without a layer, and is only the edge itself when direct.
+type LayerViolation[T comparable] struct {
+	From      T      `json:"from"`
+	To        T      `json:"to"`
+	FromLayer string `json:"from_layer"`
+	ToLayer   string `json:"to_layer"`
+	Path      []T    `json:"path"`
+}
+
+type PolicyError[T comparable] struct {
+	Violations []LayerViolation[T]
+}
+
+func (e *PolicyError[T]) Error() string {
+	return fmt.Sprintf("seeing %d layering policy violations", len(e.Violations))
+}
+
+// SetLayer assigns vertex u to a layer, adding u if needed.
+func (g *Graph[T]) SetLayer(u T, layer string) {
+	g.AddVertex(u)
+	g.Layers[u] = layer
+}
+
+// CheckLayers reports every dependency between vertices of different layers
+// whose direction isn't allowed, as from-to pairs of layer names. Vertices
+// without a layer are transparent, so depending on a layer through them
+// counts the same as depending on it directly.
+func (g *Graph[T]) CheckLayers(allowed map[[2]string]bool) []LayerViolation[T] {
+	var violations []LayerViolation[T]
+	for _, u := range g.Vertices.Sorted(g.less) {
+		from, layered := g.Layers[u]
+		if !layered {
+			continue
+		}
+
+		// search outwards, stopping at the first layered vertex on each path
+		parent := map[T]T{}
+		queue := []T{u}
+		for len(queue) > 0 {
+			w := queue[0]
+			queue = queue[1:]
+			for _, v := range g.AdjList[w].Sorted(g.less) {
+				_, seen := parent[v]
+				if seen || v == u {
+					continue
+				}
+				parent[v] = w
+				to, layered := g.Layers[v]
+				if !layered {
+					queue = append(queue, v)
+					continue
+				}
+				if to == from || allowed[[2]string{from, to}] {
+					continue
+				}
+
+				path := []T{v}
+				for path[0] != u {
+					path = append([]T{parent[path[0]]}, path...)
+				}
+				violations = append(violations, LayerViolation[T]{u, v, from, to, path})
+			}
+		}
+	}
+	return violations
+}
@@ -0,0 +1,55 @@
+package lib
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestGraph_SetLayer(t *testing.T) {
+	g := NewGraph[string]()
+	g.SetLayer("a", "ui")
+	if !g.Vertices.Has("a") || g.Layers["a"] != "ui" {
+		t.Fatal("expecting a added to the ui layer")
+	}
+}
+
+func TestGraph_CheckLayers(t *testing.T) {
+	g := NewGraph[string]()
+	g.AddEdge("page", "api")
+	g.AddEdge("api", "store")
+	g.AddEdge("page", "store")
+	g.AddEdge("page", "util")
+	g.AddEdge("util", "helpers")
+	g.AddEdge("helpers", "store")
+	g.AddEdge("store", "pool")
+	g.SetLayer("page", "ui")
+	g.SetLayer("api", "service")
+	g.SetLayer("store", "db")
+	g.SetLayer("pool", "db")
+	g.Less = func(a, b string) bool {
+		return a < b
+	}
+	allowed := map[[2]string]bool{
+		{"ui", "service"}: true,
+		{"service", "db"}: true,
+	}
+
+	// one violation per pair of vertices, along the shortest path
+	violations := g.CheckLayers(allowed)
+	expected := "[{page store ui db [page store]}]"
+	if fmt.Sprint(violations) != expected {
+		t.Fatal("expecting", expected, "got", violations)
+	}
+
+	g.RemoveEdge("page", "store")
+	violations = g.CheckLayers(allowed)
+	expected = "[{page store ui db [page util helpers store]}]"
+	if fmt.Sprint(violations) != expected {
+		t.Fatal("expecting", expected, "got", violations)
+	}
+
+	allowed[[2]string{"ui", "db"}] = true
+	if len(g.CheckLayers(allowed)) != 0 {
+		t.Fatal("expecting no violations")
+	}
+}
@@ -0,0 +1,248 @@
+package lib
+
+import (
+	"math/big"
+	"math/rand"
+	"sort"
+)
+
+// MaxCountWork bounds the work spent counting topological orders exactly,
+// beyond which CountOrders estimates the count instead.
+const MaxCountWork = 1 << 20
+
+// CountSamples is the number of random orders an estimated count averages over.
+const CountSamples = 1000
+
+// Orders returns up to limit topological orders, sequenced by comparing
+// vertices with the graph's tie-break, and resuming right after the order
+// given by after when not nil. more reports whether further orders remain.
+func (g *Graph[T]) Orders(after []T, limit int) ([][]T, bool, error) {
+	vertices, succ, indegree, err := g.indexed()
+	if err != nil {
+		return nil, false, err
+	}
+	n := len(vertices)
+	used := make([]bool, n)
+	order := make([]int, 0, n)
+
+	// tied holds while the order built so far matches after,
+	// only orders that then branch off to a later vertex come after it
+	var orders [][]T
+	var walk func(tied bool) bool
+	walk = func(tied bool) bool {
+		depth := len(order)
+		if depth == n {
+			if tied {
+				return true
+			}
+			result := make([]T, n)
+			for i, u := range order {
+				result[i] = vertices[u]
+			}
+			orders = append(orders, result)
+			return len(orders) <= limit
+		}
+		tied = tied && depth < len(after)
+
+		var ready []int
+		for u := 0; u < n; u++ {
+			if !used[u] && indegree[u] == 0 {
+				ready = append(ready, u)
+			}
+		}
+		sort.Slice(ready, func(i, j int) bool {
+			return g.less(vertices[ready[i]], vertices[ready[j]])
+		})
+
+		for _, u := range ready {
+			if tied && g.less(vertices[u], after[depth]) {
+				continue
+			}
+			used[u] = true
+			order = append(order, u)
+			for _, v := range succ[u] {
+				indegree[v]--
+			}
+			ok := walk(tied && vertices[u] == after[depth])
+			for _, v := range succ[u] {
+				indegree[v]++
+			}
+			order = order[:depth]
+			used[u] = false
+			if !ok {
+				return false
+			}
+		}
+		return true
+	}
+	walk(after != nil)
+
+	// one order past the limit tells whether there are more
+	if len(orders) > limit {
+		return orders[:limit], true, nil
+	}
+	return orders, false, nil
+}
+
+// CountOrders returns the number of topological orders and whether it's exact.
+// Orders are counted per set of already sorted vertices while that stays
+// within MaxCountWork, and estimated from CountSamples random orders beyond.
+func (g *Graph[T]) CountOrders() (*big.Int, bool, error) {
+	vertices, succ, indegree, err := g.indexed()
+	if err != nil {
+		return nil, false, err
+	}
+	n := len(vertices)
+	size := n + 1
+	for _, vs := range succ {
+		size += len(vs)
+	}
+
+	// the orders completing a set of sorted vertices don't depend
+	// on how that set was sorted, so each set is counted once
+	maxStates := MaxCountWork / size
+	sorted := make([]byte, n/8+1)
+	counts := map[string]*big.Int{}
+	var count func() *big.Int
+	count = func() *big.Int {
+		key := string(sorted)
+		if c, ok := counts[key]; ok {
+			return c
+		}
+		if len(counts) >= maxStates {
+			return nil
+		}
+
+		total := new(big.Int)
+		last := true
+		for u := 0; u < n; u++ {
+			if sorted[u/8]&(1<<(u%8)) != 0 || indegree[u] != 0 {
+				continue
+			}
+			last = false
+			sorted[u/8] |= 1 << (u % 8)
+			for _, v := range succ[u] {
+				indegree[v]--
+			}
+			c := count()
+			for _, v := range succ[u] {
+				indegree[v]++
+			}
+			sorted[u/8] &^= 1 << (u % 8)
+			if c == nil {
+				return nil
+			}
+			total.Add(total, c)
+		}
+		if last {
+			total.SetInt64(1)
+		}
+		counts[key] = total
+		return total
+	}
+	exact := count()
+	if exact != nil {
+		return exact, true, nil
+	}
+
+	// the product of how many vertices are ready at each step of a
+	// uniformly random walk is an unbiased estimate of the count
+	rng := rand.New(rand.NewSource(1))
+	sum := new(big.Float)
+	for i := 0; i < CountSamples; i++ {
+		degrees := append([]int{}, indegree...)
+		var ready []int
+		for u := 0; u < n; u++ {
+			if degrees[u] == 0 {
+				ready = append(ready, u)
+			}
+		}
+		product := big.NewFloat(1)
+		for len(ready) > 0 {
+			product.Mul(product, big.NewFloat(float64(len(ready))))
+			j := rng.Intn(len(ready))
+			u := ready[j]
+			ready[j] = ready[len(ready)-1]
+			ready = ready[:len(ready)-1]
+			for _, v := range succ[u] {
+				degrees[v]--
+				if degrees[v] == 0 {
+					ready = append(ready, v)
+				}
+			}
+		}
+		sum.Add(sum, product)
+	}
+	estimate, _ := sum.Quo(sum, big.NewFloat(CountSamples)).Int(nil)
+	return estimate, false, nil
+}
+
+// indexed numbers the vertices in topological order, returning them
+// along with the successors and indegree of each vertex by number.
+func (g *Graph[T]) indexed() ([]T, [][]int, []int, error) {
+	order, err := g.Order()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	index := map[T]int{}
+	for i, u := range order {
+		index[u] = i
+	}
+	succ := make([][]int, len(order))
+	indegree := make([]int, len(order))
+	for i, u := range order {
+		for v := range g.AdjList[u].Map {
+			succ[i] = append(succ[i], index[v])
+			indegree[index[v]]++
+		}
+		sort.Ints(succ[i])
+	}
+	return order, succ, indegree, nil
+}
+
+// AmbiguousOrderError reports pairs of vertices that could be sorted either way round.
+type AmbiguousOrderError[T comparable] struct {
+	Pairs [][2]T
+}
+
+func (e *AmbiguousOrderError[T]) Error() string {
+	return "topological order is not unique"
+}
+
+// UniqueOrder reports whether the graph has a single topological order,
+// which holds when every vertex of the order has an edge to the next one.
+func (g *Graph[T]) UniqueOrder() (bool, error) {
+	order, err := g.Order()
+	if err != nil {
+		return false, err
+	}
+	for i := 1; i < len(order); i++ {
+		if !g.AdjList[order[i-1]].Has(order[i]) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// Incomparable returns up to limit pairs of vertices without a path between
+// them either way, both within and across pairs in topological order.
+func (g *Graph[T]) Incomparable(limit int) ([][2]T, error) {
+	order, err := g.Order()
+	if err != nil {
+		return nil, err
+	}
+	var pairs [][2]T
+	for i, u := range order {
+		descendants := g.Descendants(u)
+		for _, v := range order[i+1:] {
+			if descendants.Has(v) {
+				continue
+			}
+			if len(pairs) == limit {
+				return pairs, nil
+			}
+			pairs = append(pairs, [2]T{u, v})
+		}
+	}
+	return pairs, nil
+}
@@ -0,0 +1,143 @@
+package lib
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+)
+
+func TestGraph_Orders(t *testing.T) {
+	g := NewGraph[string]()
+	g.AddEdge("a", "b")
+	g.AddEdge("a", "c")
+	g.AddEdge("b", "d")
+	g.AddEdge("c", "d")
+	g.AddVertex("e")
+	g.Less = func(a, b string) bool {
+		return a < b
+	}
+
+	orders, more, err := g.Orders(nil, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "[[a b c d e] [a b c e d] [a b e c d]]"
+	if fmt.Sprint(orders) != expected || !more {
+		t.Fatal("expecting", expected, "got", orders, more)
+	}
+
+	// resume after the last order of the page
+	orders, more, err = g.Orders(orders[2], 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orders) != 7 || more {
+		t.Fatal("expecting 7 more orders, got", orders, more)
+	}
+	if fmt.Sprint(orders[0]) != "[a c b d e]" || fmt.Sprint(orders[6]) != "[e a c b d]" {
+		t.Fatal("unexpected orders", orders)
+	}
+
+	orders, more, err = g.Orders([]string{"e", "a", "c", "b", "d"}, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orders) != 0 || more {
+		t.Fatal("expecting no orders after the last one")
+	}
+}
+
+func TestGraph_Orders_Cycle(t *testing.T) {
+	g := NewGraph[string]()
+	g.AddEdge("a", "b")
+	g.AddEdge("b", "a")
+
+	_, _, err := g.Orders(nil, 10)
+	if _, ok := err.(*CycleError[string]); !ok {
+		t.Fatal("expecting cycle error")
+	}
+	_, _, err = g.CountOrders()
+	if _, ok := err.(*CycleError[string]); !ok {
+		t.Fatal("expecting cycle error")
+	}
+}
+
+func TestGraph_CountOrders(t *testing.T) {
+	g := NewGraph[string]()
+	g.AddEdge("a", "b")
+	g.AddEdge("a", "c")
+	g.AddEdge("b", "d")
+	g.AddEdge("c", "d")
+	g.AddVertex("e")
+
+	count, exact, err := g.CountOrders()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count.Int64() != 10 || !exact {
+		t.Fatal("expecting exactly 10 orders, got", count, exact)
+	}
+
+	// too many sets of sorted vertices to count them all
+	g = NewGraph[string]()
+	for i := 0; i < 30; i++ {
+		g.AddVertex(fmt.Sprint(i))
+	}
+	count, exact, err = g.CountOrders()
+	if err != nil {
+		t.Fatal(err)
+	}
+	factorial := new(big.Int).MulRange(1, 30)
+	diff := new(big.Int).Sub(count, factorial)
+	diff.Abs(diff).Mul(diff, big.NewInt(100))
+	if exact || diff.Cmp(factorial) > 0 {
+		t.Fatal("expecting an estimate close to 30!, got", count, exact)
+	}
+}
+
+func TestGraph_UniqueOrder(t *testing.T) {
+	g := NewGraph[string]()
+	g.AddEdge("a", "b")
+	g.AddEdge("b", "c")
+	g.AddEdge("a", "c")
+
+	unique, err := g.UniqueOrder()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !unique {
+		t.Fatal("expecting a unique order")
+	}
+	pairs, err := g.Incomparable(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pairs) != 0 {
+		t.Fatal("expecting no incomparable pairs, got", pairs)
+	}
+
+	g.RemoveEdge("b", "c")
+	g.AddVertex("d")
+	g.Less = func(a, b string) bool {
+		return a < b
+	}
+	unique, err = g.UniqueOrder()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unique {
+		t.Fatal("expecting the order not to be unique")
+	}
+	pairs, err = g.Incomparable(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "[[a d] [d b] [d c] [b c]]"
+	if fmt.Sprint(pairs) != expected {
+		t.Fatal("expecting", expected, "got", pairs)
+	}
+	pairs, _ = g.Incomparable(2)
+	if fmt.Sprint(pairs) != "[[a d] [d b]]" {
+		t.Fatal("expecting the first 2 pairs, got", pairs)
+	}
+}
@@ -0,0 +1,203 @@
+package lib
+
+import "fmt"
+
+const (
+	PinFirst = "first"
+	PinLast  = "last"
+)
+
+// PinError explains why a vertex can't be pinned where it was asked to,
+// along with the dependency path getting in the way.
+type PinError[T comparable] struct {
+	Vertex T
+	Pin    string
+	Reason string
+	Path   []T
+}
+
+func (e *PinError[T]) Error() string {
+	return fmt.Sprintf("can't pin %v %s: %s", e.Vertex, e.Pin, e.Reason)
+}
+
+// SetPin pins vertex u to the first or last positions, adding u if needed.
+func (g *Graph[T]) SetPin(u T, pin string) {
+	g.AddVertex(u)
+	g.Pins[u] = pin
+}
+
+// SetLevelPin pins vertex u to the given level, adding u if needed.
+func (g *Graph[T]) SetLevelPin(u T, level int) {
+	g.AddVertex(u)
+	g.LevelPins[u] = level
+}
+
+// PinnedLevels restarts the sort and returns levels honoring every pin.
+// Vertices pinned first come before all others and vertices pinned last
+// after all others, while vertices pinned to a level are held back until
+// that level, leaving levels empty when nothing else can run before it.
+func (g *Graph[T]) PinnedLevels() ([][]T, error) {
+	err := g.checkPins()
+	if err != nil {
+		return nil, err
+	}
+
+	// levels past the vertex count would only be reached through empty levels
+	pinnedAt := map[int][]T{}
+	for _, u := range g.Vertices.Sorted(g.less) {
+		pinned, hasLevel := g.LevelPins[u]
+		if !hasLevel {
+			continue
+		}
+		if pinned > g.Vertices.Size {
+			reason := fmt.Sprintf("expecting a level of at most %d, the number of vertices", g.Vertices.Size)
+			return nil, &PinError[T]{u, pinLabel(pinned), reason, []T{u}}
+		}
+		pinnedAt[pinned] = append(pinnedAt[pinned], u)
+	}
+
+	g.ResetSort()
+	first, middle := 0, 0
+	for u := range g.Vertices.Map {
+		switch g.Pins[u] {
+		case PinFirst:
+			first++
+		case PinLast:
+		default:
+			middle++
+		}
+	}
+
+	var levels [][]T
+	sorted := NewSet[T]()
+	ready := NewSet[T]()
+	for u := range g.Sources.Map {
+		ready.Add(u)
+	}
+	for depth := 0; g.SortRemaining > 0; depth++ {
+		level := []T{}
+		var pending []T
+		for _, u := range ready.Sorted(g.less) {
+			pinned, hasLevel := g.LevelPins[u]
+			switch {
+			case first > 0 && g.Pins[u] != PinFirst:
+			case middle > 0 && g.Pins[u] == PinLast:
+			case hasLevel && pinned > depth:
+				pending = append(pending, u)
+			default:
+				level = append(level, u)
+			}
+		}
+
+		// vertices pinned here must be ready by now
+		for _, u := range pinnedAt[depth] {
+			if !ready.Has(u) {
+				return nil, &PinError[T]{u, pinLabel(depth), "depends on vertices that can't be sorted by then", g.blockedPath(u, sorted)}
+			}
+			if first > 0 && g.Pins[u] != PinFirst {
+				return nil, &PinError[T]{u, pinLabel(depth), "vertices pinned first are still being sorted", []T{u}}
+			}
+			if middle > 0 && g.Pins[u] == PinLast {
+				return nil, &PinError[T]{u, pinLabel(depth), "vertices not pinned last are still being sorted", []T{u}}
+			}
+		}
+		if len(level) == 0 && len(pending) == 0 {
+			break
+		}
+
+		for _, u := range level {
+			ready.Delete(u)
+			sorted.Add(u)
+			g.SortRemaining--
+			switch g.Pins[u] {
+			case PinFirst:
+				first--
+			case PinLast:
+			default:
+				middle--
+			}
+			for v := range g.AdjList[u].Map {
+				g.SortDegrees[v]--
+				if g.SortDegrees[v] == 0 {
+					ready.Add(v)
+				}
+			}
+		}
+		levels = append(levels, level)
+	}
+
+	g.SortLevel = NewSet[T]()
+	if g.SortRemaining > 0 {
+		// vertices held back by their pins aren't on a cycle, sort them
+		// and whatever only waits on them so the cycle is all that's left
+		var queue []T
+		for u := range ready.Map {
+			queue = append(queue, u)
+		}
+		for len(queue) > 0 {
+			u := queue[0]
+			queue = queue[1:]
+			for v := range g.AdjList[u].Map {
+				g.SortDegrees[v]--
+				if g.SortDegrees[v] == 0 {
+					queue = append(queue, v)
+				}
+			}
+		}
+		return nil, &CycleError[T]{Cycle: g.FindCycle()}
+	}
+	return levels, nil
+}
+
+// checkPins makes sure every vertex a first vertex depends on is pinned first,
+// and every vertex depending on a last vertex is pinned last.
+func (g *Graph[T]) checkPins() error {
+	for _, u := range g.Vertices.Sorted(g.less) {
+		var related *Set[T]
+		switch g.Pins[u] {
+		case PinFirst:
+			related = g.Ancestors(u)
+		case PinLast:
+			related = g.Descendants(u)
+		default:
+			continue
+		}
+		for _, v := range related.Sorted(g.less) {
+			if g.Pins[v] == g.Pins[u] {
+				continue
+			}
+			if g.Pins[u] == PinFirst {
+				return &PinError[T]{u, PinFirst, fmt.Sprintf("depends on %v, which isn't pinned first", v), g.Path(v, u)}
+			}
+			return &PinError[T]{u, PinLast, fmt.Sprintf("%v depends on it and isn't pinned last", v), g.Path(u, v)}
+		}
+	}
+	return nil
+}
+
+// blockedPath walks back from u through unsorted predecessors,
+// returning the chain of dependencies still holding u back.
+func (g *Graph[T]) blockedPath(u T, sorted *Set[T]) []T {
+	path := []T{u}
+	visited := NewSet[T]()
+	visited.Add(u)
+	for {
+		var next []T
+		for _, v := range g.RevList[u].Sorted(g.less) {
+			if !sorted.Has(v) && !visited.Has(v) {
+				next = append(next, v)
+			}
+		}
+		if len(next) == 0 {
+			break
+		}
+		u = next[0]
+		visited.Add(u)
+		path = append([]T{u}, path...)
+	}
+	return path
+}
+
+func pinLabel(level int) string {
+	return fmt.Sprintf("to level %d", level)
+}
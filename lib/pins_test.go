@@ -0,0 +1,127 @@
+package lib
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestGraph_PinnedLevels(t *testing.T) {
+	g := NewGraph[string]()
+	g.AddEdge("schema", "users")
+	g.AddEdge("schema", "orders")
+	g.AddVertex("bootstrap")
+	g.AddVertex("cleanup")
+	g.AddVertex("audit")
+	g.Less = func(a, b string) bool {
+		return a < b
+	}
+	g.SetPin("bootstrap", PinFirst)
+	g.SetPin("cleanup", PinLast)
+	g.SetLevelPin("audit", 3)
+
+	levels, err := g.PinnedLevels()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "[[bootstrap] [schema] [orders users] [audit] [cleanup]]"
+	if fmt.Sprint(levels) != expected {
+		t.Fatal("expecting", expected, "got", levels)
+	}
+
+	// levels are left empty until the pinned level
+	g.SetLevelPin("audit", 5)
+	levels, err = g.PinnedLevels()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = "[[bootstrap] [schema] [orders users] [] [] [audit] [cleanup]]"
+	if fmt.Sprint(levels) != expected {
+		t.Fatal("expecting", expected, "got", levels)
+	}
+}
+
+func TestGraph_PinnedLevels_Conflict(t *testing.T) {
+	g := NewGraph[string]()
+	g.AddEdge("schema", "seed")
+	g.AddEdge("seed", "users")
+	g.Less = func(a, b string) bool {
+		return a < b
+	}
+
+	g.SetLevelPin("users", 1)
+	_, err := g.PinnedLevels()
+	pinErr, ok := err.(*PinError[string])
+	if !ok {
+		t.Fatal("expecting pin error")
+	}
+	expected := "can't pin users to level 1: depends on vertices that can't be sorted by then"
+	if pinErr.Error() != expected || fmt.Sprint(pinErr.Path) != "[seed users]" {
+		t.Fatal("unexpected error", pinErr, pinErr.Path)
+	}
+
+	delete(g.LevelPins, "users")
+	g.SetPin("seed", PinFirst)
+	_, err = g.PinnedLevels()
+	pinErr, ok = err.(*PinError[string])
+	if !ok {
+		t.Fatal("expecting pin error")
+	}
+	expected = "can't pin seed first: depends on schema, which isn't pinned first"
+	if pinErr.Error() != expected || fmt.Sprint(pinErr.Path) != "[schema seed]" {
+		t.Fatal("unexpected error", pinErr, pinErr.Path)
+	}
+
+	delete(g.Pins, "seed")
+	g.SetPin("schema", PinLast)
+	_, err = g.PinnedLevels()
+	pinErr, ok = err.(*PinError[string])
+	if !ok {
+		t.Fatal("expecting pin error")
+	}
+	expected = "can't pin schema last: seed depends on it and isn't pinned last"
+	if pinErr.Error() != expected || fmt.Sprint(pinErr.Path) != "[schema seed]" {
+		t.Fatal("unexpected error", pinErr, pinErr.Path)
+	}
+}
+
+func TestGraph_PinnedLevels_TooDeep(t *testing.T) {
+	g := NewGraph[string]()
+	g.AddEdge("x", "y")
+	g.SetLevelPin("a", 3)
+	_, err := g.PinnedLevels()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g.SetLevelPin("a", 1000000000)
+	_, err = g.PinnedLevels()
+	pinErr, ok := err.(*PinError[string])
+	if !ok {
+		t.Fatal("expecting pin error")
+	}
+	expected := "can't pin a to level 1000000000: expecting a level of at most 3, the number of vertices"
+	if pinErr.Error() != expected {
+		t.Fatal("expecting", expected, "got", pinErr)
+	}
+}
+
+func TestGraph_PinnedLevels_Cycle(t *testing.T) {
+	g := NewGraph[string]()
+	g.AddEdge("L", "M")
+	g.AddEdge("M", "N")
+	g.AddEdge("a", "b")
+	g.AddEdge("b", "a")
+	g.SetPin("L", PinLast)
+	g.SetPin("M", PinLast)
+	g.SetPin("N", PinLast)
+
+	// last vertices held back behind the cycle aren't part of it
+	_, err := g.PinnedLevels()
+	cycleErr, ok := err.(*CycleError[string])
+	if !ok {
+		t.Fatal("expecting a cycle error, got", err)
+	}
+	if fmt.Sprint(cycleErr.Cycle) != "[a b a]" {
+		t.Fatal("expecting cycle [a b a], got", cycleErr.Cycle)
+	}
+}
@@ -0,0 +1,111 @@
+package lib
+
+// Descendants returns every vertex reachable from the given vertices.
+// The given vertices are only included when reachable from one another.
+func (g *Graph[T]) Descendants(vertices ...T) *Set[T] {
+	return reach(g.AdjList, vertices)
+}
+
+// Ancestors returns every vertex the given vertices can be reached from.
+// The given vertices are only included when reachable from one another.
+func (g *Graph[T]) Ancestors(vertices ...T) *Set[T] {
+	return reach(g.RevList, vertices)
+}
+
+func reach[T comparable](adj map[T]*Set[T], from []T) *Set[T] {
+	reached := NewSet[T]()
+	queue := append([]T{}, from...)
+	for len(queue) > 0 {
+		u := queue[0]
+		queue = queue[1:]
+		if adj[u] == nil {
+			continue
+		}
+		for v := range adj[u].Map {
+			if !reached.Has(v) {
+				reached.Add(v)
+				queue = append(queue, v)
+			}
+		}
+	}
+	return reached
+}
+
+// Induced returns the subgraph of the given vertices, adding an edge
+// between two of them whenever the first reaches the second through
+// vertices left out only. Other paths already pass through kept vertices.
+func (g *Graph[T]) Induced(vertices *Set[T]) *Graph[T] {
+	sub := g.Subgraph(vertices)
+	for _, u := range sub.Vertices.Sorted(sub.less) {
+		visited := NewSet[T]()
+		queue := []T{u}
+		for len(queue) > 0 {
+			w := queue[0]
+			queue = queue[1:]
+			for _, v := range g.AdjList[w].Sorted(g.less) {
+				if visited.Has(v) {
+					continue
+				}
+				visited.Add(v)
+				if !vertices.Has(v) {
+					queue = append(queue, v)
+				} else if v != u {
+					sub.AddEdge(u, v)
+				}
+			}
+		}
+	}
+	return sub
+}
+
+// Path returns the shortest path from u to v, or nil when v isn't reachable from u.
+// Ties between paths of the same length are broken by vertex order.
+func (g *Graph[T]) Path(u, v T) []T {
+	parent := map[T]T{}
+	visited := NewSet[T]()
+	visited.Add(u)
+	queue := []T{u}
+	for len(queue) > 0 && !visited.Has(v) {
+		w := queue[0]
+		queue = queue[1:]
+		if g.AdjList[w] == nil {
+			continue
+		}
+		for _, x := range g.AdjList[w].Sorted(g.less) {
+			if !visited.Has(x) {
+				visited.Add(x)
+				parent[x] = w
+				queue = append(queue, x)
+			}
+		}
+	}
+	if _, ok := parent[v]; !ok {
+		return nil
+	}
+
+	// walk back from v
+	path := []T{v}
+	for w := v; w != u; {
+		w = parent[w]
+		path = append(path, w)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+// OrderOf returns the vertices of set in topological order.
+func (g *Graph[T]) OrderOf(set *Set[T]) ([]T, error) {
+	order, err := g.Order()
+	if err != nil {
+		return nil, err
+	}
+	var filtered []T
+	for _, u := range order {
+		if set.Has(u) {
+			filtered = append(filtered, u)
+		}
+	}
+	return filtered, nil
+}
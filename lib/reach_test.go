@@ -0,0 +1,148 @@
+package lib
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGraph_Descendants(t *testing.T) {
+	g := NewGraph[string]()
+	g.AddEdge("a", "b")
+	g.AddEdge("b", "c")
+	g.AddEdge("b", "d")
+	g.AddEdge("e", "d")
+	g.Less = func(a, b string) bool {
+		return a < b
+	}
+
+	actual := strings.Join(g.Descendants("b").Sorted(g.less), "")
+	if actual != "cd" {
+		t.Fatal("expecting cd, got", actual)
+	}
+	actual = strings.Join(g.Descendants("a", "b").Sorted(g.less), "")
+	if actual != "bcd" {
+		t.Fatal("expecting bcd, got", actual)
+	}
+	if g.Descendants("c").Size != 0 {
+		t.Fatal("expecting no descendants for c")
+	}
+	if g.Descendants("missing").Size != 0 {
+		t.Fatal("expecting no descendants for unknown vertex")
+	}
+}
+
+func TestGraph_Ancestors(t *testing.T) {
+	g := NewGraph[string]()
+	g.AddEdge("a", "b")
+	g.AddEdge("b", "c")
+	g.AddEdge("b", "d")
+	g.AddEdge("e", "d")
+	g.Less = func(a, b string) bool {
+		return a < b
+	}
+
+	actual := strings.Join(g.Ancestors("d").Sorted(g.less), "")
+	if actual != "abe" {
+		t.Fatal("expecting abe, got", actual)
+	}
+	if g.Ancestors("a").Size != 0 {
+		t.Fatal("expecting no ancestors for a")
+	}
+}
+
+func TestGraph_Induced(t *testing.T) {
+	g := NewGraph[string]()
+	g.AddEdge("web", "lib")
+	g.AddEdge("lib", "db")
+	g.AddEdge("cli", "db")
+	g.SetVertexAttrs("web", Attrs{"owner": "frontend"})
+
+	only := NewSet[string]()
+	only.Add("web")
+	only.Add("db")
+	only.Add("cli")
+	sub := g.Induced(only)
+	if sub.Vertices.Size != 3 || sub.Vertices.Has("lib") {
+		t.Fatal("expecting only web, db and cli")
+	}
+	if !sub.AdjList["web"].Has("db") || !sub.AdjList["cli"].Has("db") {
+		t.Fatal("expecting web->db through lib, and cli->db")
+	}
+	if sub.AdjList["web"].Has("cli") || sub.AdjList["cli"].Has("web") {
+		t.Fatal("expecting web and cli unrelated")
+	}
+	if sub.VertexAttrs["web"]["owner"] != "frontend" {
+		t.Fatal("expecting attrs of web kept")
+	}
+
+	// paths through kept vertices add no edges of their own
+	g = NewGraph[string]()
+	g.AddEdge("a", "b")
+	g.AddEdge("b", "c")
+	g.AddEdge("c", "x")
+	g.AddEdge("x", "d")
+	only = NewSet[string]()
+	for _, u := range []string{"a", "b", "c", "d"} {
+		only.Add(u)
+	}
+	sub = g.Induced(only)
+	edges := 0
+	for _, u := range sub.Vertices.Items() {
+		edges += sub.AdjList[u].Size
+	}
+	if edges != 3 || !sub.AdjList["c"].Has("d") {
+		t.Fatal("expecting only a->b, b->c and c->d through x")
+	}
+}
+
+func TestGraph_Path(t *testing.T) {
+	g := NewGraph[string]()
+	g.AddEdge("a", "c")
+	g.AddEdge("a", "b")
+	g.AddEdge("b", "d")
+	g.AddEdge("c", "d")
+	g.AddEdge("d", "e")
+	g.AddEdge("a", "e")
+	g.Less = func(a, b string) bool {
+		return a < b
+	}
+
+	actual := strings.Join(g.Path("a", "d"), "")
+	if actual != "abd" {
+		t.Fatal("expecting abd, got", actual)
+	}
+	actual = strings.Join(g.Path("a", "e"), "")
+	if actual != "ae" {
+		t.Fatal("expecting ae, got", actual)
+	}
+	if g.Path("b", "c") != nil {
+		t.Fatal("expecting no path from b to c")
+	}
+	if g.Path("e", "a") != nil {
+		t.Fatal("expecting no path from e to a")
+	}
+}
+
+func TestGraph_OrderOf(t *testing.T) {
+	g := NewGraph[string]()
+	g.AddEdge("d", "c")
+	g.AddEdge("c", "b")
+	g.AddEdge("b", "a")
+
+	set := NewSet[string]()
+	set.Add("a")
+	set.Add("c")
+	order, err := g.OrderOf(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(order, "") != "ca" {
+		t.Fatal("expecting ca, got", order)
+	}
+
+	g.AddEdge("a", "d")
+	_, err = g.OrderOf(set)
+	if err == nil {
+		t.Fatal("expecting cycle error")
+	}
+}
@@ -0,0 +1,414 @@
+package lib
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Request is the document accepted by the graph endpoints, sent either as
+// a bare array of edge pairs or as an object listing nodes alongside edges.
+// In the object form, nodes and edges may also be objects carrying attrs.
+type Request struct {
+	Nodes []NodeInput
+	Edges []EdgeInput
+
+	// Workers and Resources bound how many vertices run at once when scheduling,
+	// Resources capping the vertices tagged with each resource.
+	Workers   int
+	Resources map[string]int
+
+	// Targets are the vertices a query is about,
+	// Changed the vertices whose downstream impact is wanted.
+	Targets []string
+	Changed []string
+
+	// Queries are pairs of vertices to check reachability between.
+	Queries [][]string
+
+	// Policy lists the directions allowed between the layers of vertices.
+	Policy *Policy
+
+	// Previous is an earlier order for the sort to stay close to.
+	Previous []string
+
+	// Only lists the vertices to sort, out of all the vertices of the graph.
+	Only []string
+}
+
+type Policy struct {
+	Allow [][]string `json:"allow"`
+}
+
+// Allowed returns the allowed directions as from-to pairs of layers.
+func (p *Policy) Allowed() map[[2]string]bool {
+	allowed := map[[2]string]bool{}
+	for _, pair := range p.Allow {
+		allowed[[2]string{pair[0], pair[1]}] = true
+	}
+	return allowed
+}
+
+type NodeInput struct {
+	ID        string
+	Attrs     Attrs
+	Duration  *float64
+	Priority  *float64
+	Resources []string
+	Layer     string
+
+	// Pin holds a vertex first or last, Level at a given level.
+	Pin   string
+	Level *int
+}
+
+type EdgeInput struct {
+	From  string
+	To    string
+	Attrs Attrs
+
+	// Soft edges are only honored when they don't close a cycle.
+	Soft bool
+}
+
+type requestDocument struct {
+	Nodes     []interface{}  `json:"nodes"`
+	Edges     []interface{}  `json:"edges"`
+	Workers   *int           `json:"workers"`
+	Resources map[string]int `json:"resources"`
+	Targets   []string       `json:"targets"`
+	Changed   []string       `json:"changed"`
+	Queries   [][]string     `json:"queries"`
+	Policy    *Policy        `json:"policy"`
+	Previous  []string       `json:"previous"`
+	Only      []string       `json:"only"`
+}
+
+type NodeError struct {
+	Index  int    `json:"index"`
+	Reason string `json:"reason"`
+}
+
+type EdgeError struct {
+	Index  int    `json:"index"`
+	Reason string `json:"reason"`
+}
+
+type ValidationError struct {
+	Nodes []NodeError
+	Edges []EdgeError
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Nodes) == 0 {
+		return fmt.Sprintf("seeing %d invalid edges", len(e.Edges))
+	}
+	return fmt.Sprintf("seeing %d invalid nodes and %d invalid edges", len(e.Nodes), len(e.Edges))
+}
+
+// DecodeRequest reads and validates a request document.
+func DecodeRequest(r io.Reader) (*Request, error) {
+	var raw json.RawMessage
+	err := json.NewDecoder(r).Decode(&raw)
+	if err != nil {
+		return nil, &DecodeError{err}
+	}
+
+	// the array form only carries edges,
+	// numbers are kept as written so attrs round-trip
+	var doc requestDocument
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if bytes.HasPrefix(bytes.TrimSpace(raw), []byte("{")) {
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&doc)
+	} else {
+		err = dec.Decode(&doc.Edges)
+	}
+	if err != nil {
+		return nil, &DecodeError{err}
+	}
+
+	// options
+	req := new(Request)
+	req.Workers = 1
+	if doc.Workers != nil {
+		if *doc.Workers < 1 {
+			return nil, &OptionError{"workers", fmt.Sprint(*doc.Workers), "expecting at least 1 worker"}
+		}
+		req.Workers = *doc.Workers
+	}
+	for tag, capacity := range doc.Resources {
+		if capacity < 1 {
+			return nil, &OptionError{"resources", tag, "expecting a capacity of at least 1"}
+		}
+	}
+	req.Resources = doc.Resources
+	req.Targets = doc.Targets
+	req.Changed = doc.Changed
+	for i, query := range doc.Queries {
+		if len(query) != 2 {
+			return nil, &OptionError{"queries", fmt.Sprint(i), "expecting 2 vertices"}
+		}
+	}
+	req.Queries = doc.Queries
+	if doc.Policy != nil {
+		for i, pair := range doc.Policy.Allow {
+			if len(pair) != 2 || pair[0] == "" || pair[1] == "" {
+				return nil, &OptionError{"policy", fmt.Sprint(i), "expecting 2 layers"}
+			}
+		}
+	}
+	req.Policy = doc.Policy
+	listed := map[string]bool{}
+	for _, u := range doc.Previous {
+		if listed[u] {
+			return nil, &OptionError{"previous", u, "listed twice"}
+		}
+		listed[u] = true
+	}
+	req.Previous = doc.Previous
+	req.Only = doc.Only
+
+	validationErr := new(ValidationError)
+	for i, item := range doc.Nodes {
+		node, reason := parseNode(item)
+		for _, tag := range node.Resources {
+			_, declared := doc.Resources[tag]
+			if reason == "" && !declared {
+				reason = "unknown resource " + tag
+			}
+		}
+		if reason != "" {
+			validationErr.Nodes = append(validationErr.Nodes, NodeError{i, reason})
+			continue
+		}
+		req.Nodes = append(req.Nodes, node)
+	}
+	req.Edges, err = ParseEdges(doc.Edges)
+	if err != nil {
+		validationErr.Edges = err.(*ValidationError).Edges
+	}
+
+	if len(validationErr.Nodes) > 0 || len(validationErr.Edges) > 0 {
+		return nil, validationErr
+	}
+	return req, nil
+}
+
+func parseNode(item interface{}) (NodeInput, string) {
+	var node NodeInput
+	var reason string
+	obj, ok := item.(map[string]interface{})
+	if !ok {
+		node.ID, reason = parseVertex(item, "node")
+		return node, reason
+	}
+
+	reason = checkFields(obj, "id", "attrs", "duration", "priority", "resources", "layer", "pin", "level")
+	if reason != "" {
+		return node, reason
+	}
+	node.ID, reason = parseVertex(obj["id"], "id")
+	if reason != "" {
+		return node, reason
+	}
+	node.Duration, reason = parseNonNegative(obj["duration"], "duration")
+	if reason != "" {
+		return node, reason
+	}
+	node.Priority, reason = parseNumber(obj["priority"], "priority")
+	if reason != "" {
+		return node, reason
+	}
+	node.Resources, reason = parseStrings(obj["resources"], "resources")
+	if reason != "" {
+		return node, reason
+	}
+	tagged := map[string]bool{}
+	for _, tag := range node.Resources {
+		if tagged[tag] {
+			return node, "duplicate resource " + tag
+		}
+		tagged[tag] = true
+	}
+	if obj["layer"] != nil {
+		node.Layer, reason = parseVertex(obj["layer"], "layer")
+		if reason != "" {
+			return node, reason
+		}
+	}
+	if obj["pin"] != nil {
+		node.Pin, _ = obj["pin"].(string)
+		if node.Pin != PinFirst && node.Pin != PinLast {
+			return node, "pin must be first or last"
+		}
+	}
+	level, reason := parseNonNegative(obj["level"], "level")
+	if reason != "" {
+		return node, reason
+	}
+	if level != nil {
+		if *level != float64(int(*level)) {
+			return node, "level is not an integer"
+		}
+		if node.Pin != "" {
+			return node, "expecting either pin or level"
+		}
+		node.Level = new(int)
+		*node.Level = int(*level)
+	}
+	node.Attrs, reason = parseAttrs(obj["attrs"])
+	return node, reason
+}
+
+// ParseEdges validates a decoded json array of edges,
+// reporting every malformed edge along with its index.
+func ParseEdges(input []interface{}) ([]EdgeInput, error) {
+	var edges []EdgeInput
+	var invalid []EdgeError
+	for i, item := range input {
+		edge, reason := parseEdge(item)
+		if reason != "" {
+			invalid = append(invalid, EdgeError{i, reason})
+			continue
+		}
+		edges = append(edges, edge)
+	}
+
+	if len(invalid) > 0 {
+		return nil, &ValidationError{Edges: invalid}
+	}
+	return edges, nil
+}
+
+func parseEdge(item interface{}) (EdgeInput, string) {
+	var edge EdgeInput
+	var reason string
+	obj, ok := item.(map[string]interface{})
+	if ok {
+		reason = checkFields(obj, "from", "to", "attrs", "soft")
+		if reason != "" {
+			return edge, reason
+		}
+		if obj["soft"] != nil {
+			soft, ok := obj["soft"].(bool)
+			if !ok {
+				return edge, "soft is not a boolean"
+			}
+			edge.Soft = soft
+		}
+		edge.From, reason = parseVertex(obj["from"], "from")
+		if reason != "" {
+			return edge, reason
+		}
+		edge.To, reason = parseVertex(obj["to"], "to")
+		if reason != "" {
+			return edge, reason
+		}
+		edge.Attrs, reason = parseAttrs(obj["attrs"])
+		return edge, reason
+	}
+
+	pair, ok := item.([]interface{})
+	if !ok {
+		return edge, "expecting an array of 2 vertices"
+	}
+	if len(pair) != 2 {
+		return edge, fmt.Sprintf("expecting 2 vertices, got %d", len(pair))
+	}
+	edge.From, reason = parseVertex(pair[0], "vertex 0")
+	if reason != "" {
+		return edge, reason
+	}
+	edge.To, reason = parseVertex(pair[1], "vertex 1")
+	return edge, reason
+}
+
+func parseVertex(value interface{}, name string) (string, string) {
+	if value == nil {
+		return "", "missing " + name
+	}
+	vertex, ok := value.(string)
+	if !ok {
+		return "", name + " is not a string"
+	}
+	if vertex == "" {
+		return "", name + " is empty"
+	}
+	return vertex, ""
+}
+
+func parseNumber(value interface{}, name string) (*float64, string) {
+	if value == nil {
+		return nil, ""
+	}
+	literal, ok := value.(json.Number)
+	if !ok {
+		return nil, name + " is not a number"
+	}
+	number, err := literal.Float64()
+	if err != nil {
+		return nil, name + " is out of range"
+	}
+	return &number, ""
+}
+
+func parseNonNegative(value interface{}, name string) (*float64, string) {
+	number, reason := parseNumber(value, name)
+	if number != nil && *number < 0 {
+		return nil, name + " is negative"
+	}
+	return number, reason
+}
+
+func parseStrings(value interface{}, name string) ([]string, string) {
+	if value == nil {
+		return nil, ""
+	}
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, name + " is not an array"
+	}
+	var strings []string
+	for _, item := range items {
+		str, ok := item.(string)
+		if !ok || str == "" {
+			return nil, name + " must only contain non-empty strings"
+		}
+		strings = append(strings, str)
+	}
+	return strings, ""
+}
+
+func parseAttrs(value interface{}) (Attrs, string) {
+	if value == nil {
+		return nil, ""
+	}
+	attrs, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, "attrs is not an object"
+	}
+	return attrs, ""
+}
+
+// checkFields reports the first field of obj, in lexical order, that isn't allowed.
+func checkFields(obj map[string]interface{}, allowed ...string) string {
+	var unknown []string
+	for k := range obj {
+		known := false
+		for _, field := range allowed {
+			known = known || k == field
+		}
+		if !known {
+			unknown = append(unknown, k)
+		}
+	}
+	if len(unknown) == 0 {
+		return ""
+	}
+	sort.Strings(unknown)
+	return "unknown field " + unknown[0]
+}
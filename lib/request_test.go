@@ -0,0 +1,366 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDecodeRequest(t *testing.T) {
+	req, err := DecodeRequest(strings.NewReader(`[["a", "b"]]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(req.Nodes) != 0 || len(req.Edges) != 1 {
+		t.Fatal("unexpected array form request", req)
+	}
+	if req.Edges[0].From != "a" || req.Edges[0].To != "b" {
+		t.Fatal("expecting edge a->b")
+	}
+
+	req, err = DecodeRequest(strings.NewReader(`{"nodes": ["c", "d"], "edges": [["a", "b"]]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(req.Nodes) != 2 || req.Nodes[0].ID != "c" || req.Nodes[1].ID != "d" {
+		t.Fatal("expecting nodes c and d")
+	}
+	if len(req.Edges) != 1 {
+		t.Fatal("expecting 1 edge")
+	}
+}
+
+func TestDecodeRequest_Attrs(t *testing.T) {
+	body := `{
+		"nodes": [{"id": "a", "attrs": {"owner": "infra"}}, "b"],
+		"edges": [{"from": "a", "to": "b", "attrs": {"kind": "build"}}, ["b", "c"]]
+	}`
+	req, err := DecodeRequest(strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Nodes[0].ID != "a" || req.Nodes[0].Attrs["owner"] != "infra" {
+		t.Fatal("expecting owner attr on a")
+	}
+	if req.Nodes[1].ID != "b" || req.Nodes[1].Attrs != nil {
+		t.Fatal("expecting plain node b")
+	}
+	edge := req.Edges[0]
+	if edge.From != "a" || edge.To != "b" || edge.Attrs["kind"] != "build" {
+		t.Fatal("expecting kind attr on a->b")
+	}
+
+	// numbers are kept as written
+	body = `{"nodes": [{"id": "a", "attrs": {"build": 9007199254740993, "ratio": 0.10}}]}`
+	req, err = DecodeRequest(strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	attrs, err := json.Marshal(req.Nodes[0].Attrs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"build":9007199254740993,"ratio":0.10}`
+	if string(attrs) != expected {
+		t.Fatal("expecting", expected, "got", string(attrs))
+	}
+
+	body = `{
+		"nodes": [{"attrs": {}}, {"id": "a", "attrs": 1}, {"id": "a", "owner": "x"}],
+		"edges": [{"from": "a"}, {"from": "a", "to": 2}]
+	}`
+	_, err = DecodeRequest(strings.NewReader(body))
+	validationErr, ok := err.(*ValidationError)
+	if !ok {
+		t.Fatal("expecting validation error")
+	}
+	expected = "[{0 missing id} {1 attrs is not an object} {2 unknown field owner}]"
+	if fmt.Sprint(validationErr.Nodes) != expected {
+		t.Fatal("unexpected node errors", validationErr.Nodes)
+	}
+	expected = "[{0 missing to} {1 to is not a string}]"
+	if fmt.Sprint(validationErr.Edges) != expected {
+		t.Fatal("unexpected edge errors", validationErr.Edges)
+	}
+}
+
+func TestDecodeRequest_Invalid(t *testing.T) {
+	_, err := DecodeRequest(strings.NewReader(`{"nodes": ["a", 1, ""], "edges": [["a"]]}`))
+	validationErr, ok := err.(*ValidationError)
+	if !ok {
+		t.Fatal("expecting validation error")
+	}
+	expected := []NodeError{
+		{1, "node is not a string"},
+		{2, "node is empty"},
+	}
+	if fmt.Sprint(validationErr.Nodes) != fmt.Sprint(expected) {
+		t.Fatal("unexpected node errors", validationErr.Nodes)
+	}
+	if len(validationErr.Edges) != 1 {
+		t.Fatal("expecting 1 edge error")
+	}
+	if validationErr.Error() != "seeing 2 invalid nodes and 1 invalid edges" {
+		t.Fatal("unexpected message", validationErr.Error())
+	}
+
+	_, err = DecodeRequest(strings.NewReader(`{"vertices": ["a"]}`))
+	if _, ok := err.(*DecodeError); !ok {
+		t.Fatal("expecting decode error on unknown field")
+	}
+
+	_, err = DecodeRequest(strings.NewReader(`"a"`))
+	if _, ok := err.(*DecodeError); !ok {
+		t.Fatal("expecting decode error on bad document")
+	}
+}
+
+func TestDecodeRequest_Duration(t *testing.T) {
+	body := `{"nodes": [{"id": "a", "duration": 2.5}, "b"]}`
+	req, err := DecodeRequest(strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *req.Nodes[0].Duration != 2.5 || req.Nodes[1].Duration != nil {
+		t.Fatal("expecting duration on a only")
+	}
+
+	body = `{"nodes": [{"id": "a", "duration": "1"}, {"id": "b", "duration": -1}]}`
+	_, err = DecodeRequest(strings.NewReader(body))
+	validationErr, ok := err.(*ValidationError)
+	if !ok {
+		t.Fatal("expecting validation error")
+	}
+	expected := "[{0 duration is not a number} {1 duration is negative}]"
+	if fmt.Sprint(validationErr.Nodes) != expected {
+		t.Fatal("unexpected node errors", validationErr.Nodes)
+	}
+}
+
+func TestDecodeRequest_Priority(t *testing.T) {
+	body := `{"nodes": [{"id": "a", "priority": -2}, {"id": "b", "priority": "high"}]}`
+	_, err := DecodeRequest(strings.NewReader(body))
+	validationErr, ok := err.(*ValidationError)
+	if !ok {
+		t.Fatal("expecting validation error")
+	}
+	expected := "[{1 priority is not a number}]"
+	if fmt.Sprint(validationErr.Nodes) != expected {
+		t.Fatal("unexpected node errors", validationErr.Nodes)
+	}
+
+	req, err := DecodeRequest(strings.NewReader(`{"nodes": [{"id": "a", "priority": -2}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *req.Nodes[0].Priority != -2 {
+		t.Fatal("expecting priority of -2")
+	}
+}
+
+func TestDecodeRequest_Soft(t *testing.T) {
+	body := `{"edges": [{"from": "a", "to": "b", "soft": true}, {"from": "b", "to": "c", "soft": "yes"}]}`
+	_, err := DecodeRequest(strings.NewReader(body))
+	validationErr, ok := err.(*ValidationError)
+	if !ok {
+		t.Fatal("expecting validation error")
+	}
+	expected := "[{1 soft is not a boolean}]"
+	if fmt.Sprint(validationErr.Edges) != expected {
+		t.Fatal("unexpected edge errors", validationErr.Edges)
+	}
+
+	body = `{"edges": [{"from": "a", "to": "b", "soft": true}, {"from": "b", "to": "c"}, ["c", "d"]]}`
+	req, err := DecodeRequest(strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !req.Edges[0].Soft || req.Edges[1].Soft || req.Edges[2].Soft {
+		t.Fatal("expecting only a->b to be soft")
+	}
+}
+
+func TestDecodeRequest_Pins(t *testing.T) {
+	body := `{"nodes": [
+		{"id": "a", "pin": "middle"},
+		{"id": "b", "level": 1.5},
+		{"id": "c", "pin": "first", "level": 0},
+		{"id": "d", "level": -1}
+	]}`
+	_, err := DecodeRequest(strings.NewReader(body))
+	validationErr, ok := err.(*ValidationError)
+	if !ok {
+		t.Fatal("expecting validation error")
+	}
+	expected := "[{0 pin must be first or last} {1 level is not an integer} " +
+		"{2 expecting either pin or level} {3 level is negative}]"
+	if fmt.Sprint(validationErr.Nodes) != expected {
+		t.Fatal("unexpected node errors", validationErr.Nodes)
+	}
+
+	body = `{"nodes": [{"id": "a", "pin": "last"}, {"id": "b", "level": 2}]}`
+	req, err := DecodeRequest(strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Nodes[0].Pin != PinLast || *req.Nodes[1].Level != 2 {
+		t.Fatal("expecting a pinned last and b to level 2")
+	}
+}
+
+func TestDecodeRequest_Previous(t *testing.T) {
+	req, err := DecodeRequest(strings.NewReader(`{"previous": ["a", "b"], "edges": [["a", "b"]]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(req.Previous) != "[a b]" {
+		t.Fatal("expecting previous order [a b]")
+	}
+
+	_, err = DecodeRequest(strings.NewReader(`{"previous": ["a", "b", "a"]}`))
+	if _, ok := err.(*OptionError); !ok {
+		t.Fatal("expecting option error on previous")
+	}
+}
+
+func TestDecodeRequest_Workers(t *testing.T) {
+	req, err := DecodeRequest(strings.NewReader(`[["a", "b"]]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Workers != 1 {
+		t.Fatal("expecting 1 worker by default")
+	}
+
+	body := `{"workers": 3, "resources": {"gpu": 1}, "nodes": [{"id": "a", "resources": ["gpu"]}]}`
+	req, err = DecodeRequest(strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Workers != 3 || req.Resources["gpu"] != 1 {
+		t.Fatal("expecting 3 workers and a gpu")
+	}
+	if fmt.Sprint(req.Nodes[0].Resources) != "[gpu]" {
+		t.Fatal("expecting gpu tag on a")
+	}
+
+	_, err = DecodeRequest(strings.NewReader(`{"workers": 0}`))
+	if _, ok := err.(*OptionError); !ok {
+		t.Fatal("expecting option error on workers")
+	}
+	_, err = DecodeRequest(strings.NewReader(`{"resources": {"gpu": 0}}`))
+	if _, ok := err.(*OptionError); !ok {
+		t.Fatal("expecting option error on resources")
+	}
+
+	body = `{"resources": {"gpu": 1}, "nodes": [
+		{"id": "a", "resources": ["tpu"]},
+		{"id": "b", "resources": [1]},
+		{"id": "c", "resources": ["gpu", "gpu"]}
+	]}`
+	_, err = DecodeRequest(strings.NewReader(body))
+	validationErr, ok := err.(*ValidationError)
+	if !ok {
+		t.Fatal("expecting validation error")
+	}
+	expected := "[{0 unknown resource tpu} {1 resources must only contain non-empty strings} " +
+		"{2 duplicate resource gpu}]"
+	if fmt.Sprint(validationErr.Nodes) != expected {
+		t.Fatal("unexpected node errors", validationErr.Nodes)
+	}
+}
+
+func TestDecodeRequest_Queries(t *testing.T) {
+	req, err := DecodeRequest(strings.NewReader(`{"queries": [["a", "b"]], "edges": [["a", "b"]]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(req.Queries) != "[[a b]]" {
+		t.Fatal("unexpected queries", req.Queries)
+	}
+
+	_, err = DecodeRequest(strings.NewReader(`{"queries": [["a", "b"], ["a"]]}`))
+	optionErr, ok := err.(*OptionError)
+	if !ok || optionErr.Value != "1" {
+		t.Fatal("expecting option error on query 1")
+	}
+}
+
+func TestDecodeRequest_Policy(t *testing.T) {
+	body := `{
+		"policy": {"allow": [["ui", "service"]]},
+		"nodes": [{"id": "page", "layer": "ui"}, {"id": "api", "layer": 1}]
+	}`
+	_, err := DecodeRequest(strings.NewReader(body))
+	validationErr, ok := err.(*ValidationError)
+	if !ok {
+		t.Fatal("expecting validation error")
+	}
+	if fmt.Sprint(validationErr.Nodes) != "[{1 layer is not a string}]" {
+		t.Fatal("unexpected node errors", validationErr.Nodes)
+	}
+
+	body = `{"policy": {"allow": [["ui", "service"]]}, "nodes": [{"id": "page", "layer": "ui"}]}`
+	req, err := DecodeRequest(strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Nodes[0].Layer != "ui" {
+		t.Fatal("expecting page in the ui layer")
+	}
+	if !req.Policy.Allowed()[[2]string{"ui", "service"}] {
+		t.Fatal("expecting ui to service to be allowed")
+	}
+
+	_, err = DecodeRequest(strings.NewReader(`{"policy": {"allow": [["ui"]]}}`))
+	if _, ok := err.(*OptionError); !ok {
+		t.Fatal("expecting option error on policy")
+	}
+}
+
+func TestParseEdges(t *testing.T) {
+	var input []interface{}
+	err := json.Unmarshal([]byte(`[["a", "b"], ["b", "c"]]`), &input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edges, err := ParseEdges(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(edges) != "[{a b map[] false} {b c map[] false}]" {
+		t.Fatal("unexpected edges", edges)
+	}
+}
+
+func TestParseEdges_Invalid(t *testing.T) {
+	var input []interface{}
+	body := `[["a", "b"], ["a"], [], ["a", "b", "c"], ["a", 1], ["", "b"], "ab", null]`
+	err := json.Unmarshal([]byte(body), &input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = ParseEdges(input)
+	validationErr, ok := err.(*ValidationError)
+	if !ok {
+		t.Fatal("expecting validation error")
+	}
+	expected := []EdgeError{
+		{1, "expecting 2 vertices, got 1"},
+		{2, "expecting 2 vertices, got 0"},
+		{3, "expecting 2 vertices, got 3"},
+		{4, "vertex 1 is not a string"},
+		{5, "vertex 0 is empty"},
+		{6, "expecting an array of 2 vertices"},
+		{7, "expecting an array of 2 vertices"},
+	}
+	if fmt.Sprint(validationErr.Edges) != fmt.Sprint(expected) {
+		t.Fatal("unexpected edge errors", validationErr.Edges)
+	}
+	if validationErr.Error() != "seeing 7 invalid edges" {
+		t.Fatal("unexpected message", validationErr.Error())
+	}
+}
@@ -0,0 +1,211 @@
+package lib
+
+import (
+	"container/heap"
+	"fmt"
+)
+
+// Timing holds the earliest and latest times a vertex can run
+// without delaying the graph as a whole.
+type Timing struct {
+	EarliestStart  float64
+	EarliestFinish float64
+	LatestStart    float64
+	LatestFinish   float64
+	Slack          float64
+}
+
+// CriticalPath computes the longest weighted path through the graph,
+// along with the timing of every vertex. Vertex weights are durations.
+func (g *Graph[T]) CriticalPath() ([]T, map[T]*Timing, error) {
+	order, err := g.Order()
+	if err != nil || len(order) == 0 {
+		return nil, nil, err
+	}
+
+	// forward pass, remembering which predecessor each vertex waits on
+	timings := map[T]*Timing{}
+	critical := map[T]T{}
+	for _, u := range order {
+		timings[u] = new(Timing)
+	}
+	var length float64
+	last := order[0]
+	for _, u := range order {
+		t := timings[u]
+		t.EarliestFinish = t.EarliestStart + g.Weight(u)
+		if t.EarliestFinish > length {
+			length = t.EarliestFinish
+			last = u
+		}
+		for _, v := range g.AdjList[u].Sorted(g.less) {
+			_, waiting := critical[v]
+			if !waiting || t.EarliestFinish > timings[v].EarliestStart {
+				timings[v].EarliestStart = t.EarliestFinish
+				critical[v] = u
+			}
+		}
+	}
+
+	// backward pass
+	for i := len(order) - 1; i >= 0; i-- {
+		u := order[i]
+		t := timings[u]
+		t.LatestFinish = length
+		for v := range g.AdjList[u].Map {
+			if timings[v].LatestStart < t.LatestFinish {
+				t.LatestFinish = timings[v].LatestStart
+			}
+		}
+		t.LatestStart = t.LatestFinish - g.Weight(u)
+		t.Slack = t.LatestStart - t.EarliestStart
+	}
+
+	// walk back from the vertex finishing last
+	path := []T{last}
+	for {
+		u, exists := critical[path[len(path)-1]]
+		if !exists {
+			break
+		}
+		path = append(path, u)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path, timings, nil
+}
+
+// Slot places a vertex on a worker for a span of time.
+type Slot[T comparable] struct {
+	Vertex T
+	Worker int
+	Start  float64
+	Finish float64
+}
+
+// Schedule plans the graph on a fixed pool of workers using list scheduling.
+// Ready vertices start by level first, then by longest remaining path, and
+// only once each resource they're tagged with has spare capacity.
+// Resources missing from capacities are unlimited.
+func (g *Graph[T]) Schedule(workers int, capacities map[string]int, tags map[T][]string) ([]Slot[T], error) {
+	if workers < 1 {
+		return nil, fmt.Errorf("expecting at least 1 worker, got %d", workers)
+	}
+	g.ResetSort()
+	levels, err := g.Levels()
+	if err != nil {
+		return nil, err
+	}
+	depth := map[T]int{}
+	var order []T
+	for d, level := range levels {
+		for _, u := range level {
+			depth[u] = d
+			order = append(order, u)
+		}
+	}
+
+	// longest remaining path from each vertex
+	tail := map[T]float64{}
+	for i := len(order) - 1; i >= 0; i-- {
+		u := order[i]
+		for v := range g.AdjList[u].Map {
+			if tail[v] > tail[u] {
+				tail[u] = tail[v]
+			}
+		}
+		tail[u] += g.Weight(u)
+	}
+	priority := func(a, b T) bool {
+		if depth[a] != depth[b] {
+			return depth[a] < depth[b]
+		}
+		if tail[a] != tail[b] {
+			return tail[a] > tail[b]
+		}
+		return g.less(a, b)
+	}
+
+	degrees := map[T]int{}
+	for v, d := range g.Indegree {
+		degrees[v] = d
+	}
+	ready := NewSet[T]()
+	for u := range g.Sources.Map {
+		ready.Add(u)
+	}
+
+	// workers past the vertex count would never get anything to run,
+	// the lowest numbered free worker takes the next vertex
+	if workers > g.Vertices.Size {
+		workers = g.Vertices.Size
+	}
+	free := &vertexHeap[int]{less: func(a, b int) bool { return a < b }}
+	for i := 0; i < workers; i++ {
+		heap.Push(free, i)
+	}
+	used := map[string]int{}
+	fits := func(u T) bool {
+		for _, tag := range tags[u] {
+			capacity, limited := capacities[tag]
+			if limited && used[tag] >= capacity {
+				return false
+			}
+		}
+		return true
+	}
+
+	var slots []Slot[T]
+	var running []Slot[T]
+	now := 0.0
+	for ready.Size > 0 || len(running) > 0 {
+		// start whatever fits right now
+		for _, u := range ready.Sorted(priority) {
+			if free.Len() == 0 {
+				break
+			}
+			if !fits(u) {
+				continue
+			}
+			worker := heap.Pop(free).(int)
+			for _, tag := range tags[u] {
+				used[tag]++
+			}
+			ready.Delete(u)
+			slot := Slot[T]{u, worker, now, now + g.Weight(u)}
+			slots = append(slots, slot)
+			running = append(running, slot)
+		}
+		if len(running) == 0 {
+			return nil, fmt.Errorf("cannot schedule %v within resource capacities", ready.Sorted(priority)[0])
+		}
+
+		// advance to the next finish, releasing everything done by then
+		now = running[0].Finish
+		for _, slot := range running {
+			if slot.Finish < now {
+				now = slot.Finish
+			}
+		}
+		var pending []Slot[T]
+		for _, slot := range running {
+			if slot.Finish > now {
+				pending = append(pending, slot)
+				continue
+			}
+			heap.Push(free, slot.Worker)
+			for _, tag := range tags[slot.Vertex] {
+				used[tag]--
+			}
+			for v := range g.AdjList[slot.Vertex].Map {
+				degrees[v]--
+				if degrees[v] == 0 {
+					ready.Add(v)
+				}
+			}
+		}
+		running = pending
+	}
+	return slots, nil
+}
@@ -0,0 +1,126 @@
+package lib
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestGraph_Weight(t *testing.T) {
+	g := NewGraph[string]()
+	g.AddEdge("a", "b")
+	g.SetWeight("a", 3)
+	g.SetWeight("c", 0)
+	if g.Weight("a") != 3 {
+		t.Fatal("expecting weight of 3 for a")
+	}
+	if g.Weight("b") != 1 {
+		t.Fatal("expecting default weight of 1 for b")
+	}
+	if !g.Vertices.Has("c") || g.Weight("c") != 0 {
+		t.Fatal("expecting c added with weight of 0")
+	}
+}
+
+func TestGraph_CriticalPath(t *testing.T) {
+	g := NewGraph[string]()
+	g.AddEdge("a", "b")
+	g.AddEdge("a", "c")
+	g.AddEdge("b", "d")
+	g.AddEdge("c", "d")
+	g.AddVertex("e")
+	g.SetWeight("a", 3)
+	g.SetWeight("b", 2)
+	g.SetWeight("c", 5)
+	g.SetWeight("d", 1)
+
+	path, timings, err := g.CriticalPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(path, "") != "acd" {
+		t.Fatal("expecting critical path acd, got", path)
+	}
+
+	expected := map[string]Timing{
+		"a": {0, 3, 0, 3, 0},
+		"b": {3, 5, 6, 8, 3},
+		"c": {3, 8, 3, 8, 0},
+		"d": {8, 9, 8, 9, 0},
+		"e": {0, 1, 8, 9, 8},
+	}
+	for u, timing := range expected {
+		if *timings[u] != timing {
+			t.Fatal("unexpected timing for", u, *timings[u])
+		}
+	}
+}
+
+func TestGraph_CriticalPath_Cycle(t *testing.T) {
+	g := NewGraph[string]()
+	g.AddEdge("a", "b")
+	g.AddEdge("b", "a")
+	_, _, err := g.CriticalPath()
+	if _, ok := err.(*CycleError[string]); !ok {
+		t.Fatal("expecting cycle error")
+	}
+}
+
+func TestGraph_Schedule(t *testing.T) {
+	g := NewGraph[string]()
+	g.AddEdge("a", "c")
+	g.AddEdge("b", "c")
+	g.AddVertex("d")
+	g.SetWeight("a", 2)
+	g.SetWeight("d", 3)
+
+	slots, err := g.Schedule(2, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "[{a 0 0 2} {d 1 0 3} {b 0 2 3} {c 0 3 4}]"
+	if fmt.Sprint(slots) != expected {
+		t.Fatal("expecting", expected, "got", slots)
+	}
+
+	// spare workers stay idle rather than being set up one by one
+	slots, err = g.Schedule(2000000000, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = "[{a 0 0 2} {d 1 0 3} {b 2 0 1} {c 0 2 3}]"
+	if fmt.Sprint(slots) != expected {
+		t.Fatal("expecting", expected, "got", slots)
+	}
+
+	_, err = g.Schedule(0, nil, nil)
+	if err == nil {
+		t.Fatal("expecting error without workers")
+	}
+}
+
+func TestGraph_Schedule_Resources(t *testing.T) {
+	g := NewGraph[string]()
+	g.AddVertex("a")
+	g.AddVertex("b")
+	g.AddVertex("c")
+	tags := map[string][]string{
+		"a": {"gpu"},
+		"b": {"gpu"},
+		"c": {"disk"},
+	}
+
+	slots, err := g.Schedule(2, map[string]int{"gpu": 1}, tags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "[{a 0 0 1} {c 1 0 1} {b 0 1 2}]"
+	if fmt.Sprint(slots) != expected {
+		t.Fatal("expecting", expected, "got", slots)
+	}
+
+	_, err = g.Schedule(2, map[string]int{"gpu": 0}, tags)
+	if err == nil {
+		t.Fatal("expecting error when a resource has no capacity")
+	}
+}
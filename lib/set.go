@@ -1,5 +1,9 @@
 package lib
 
+import (
+	"sort"
+)
+
 type Set[T comparable] struct {
 	Map  map[T]bool
 	Size int
@@ -51,3 +55,12 @@ func (s *Set[T]) Items() []T {
 	}
 	return items
 }
+
+// Sorted returns the items of the set ordered by less.
+func (s *Set[T]) Sorted(less func(a, b T) bool) []T {
+	items := s.Items()
+	sort.Slice(items, func(i, j int) bool {
+		return less(items[i], items[j])
+	})
+	return items
+}
@@ -113,3 +113,20 @@ func TestSet_GetItems(t *testing.T) {
 		t.Fatal("expecting 4")
 	}
 }
+
+func TestSet_Sorted(t *testing.T) {
+	s := NewSet[int]()
+	s.Add(3)
+	s.Add(1)
+	s.Add(4)
+	s.Add(2)
+
+	items := s.Sorted(func(a, b int) bool {
+		return a < b
+	})
+	for i, item := range items {
+		if item != i+1 {
+			t.Fatal("expecting ascending order")
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package lib
+
+import "sort"
+
+// StableOrder restarts the sort and returns every vertex in topological order,
+// staying close to a previous order by always picking the ready vertex that
+// came first in it. Vertices missing from previous come after the others,
+// ordered by the graph's tie-break. Also returns the vertices that moved.
+func (g *Graph[T]) StableOrder(previous []T) ([]T, []T, error) {
+	index := map[T]int{}
+	for i, u := range previous {
+		index[u] = i
+	}
+	order, err := g.OrderBy(func(a, b T) bool {
+		i, knownA := index[a]
+		j, knownB := index[b]
+		if knownA && knownB {
+			return i < j
+		}
+		if knownA != knownB {
+			return knownA
+		}
+		return g.less(a, b)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return order, Moved(previous, order), nil
+}
+
+// Moved returns the fewest vertices of order, in order, that have to move
+// for the vertices shared with previous to appear in the same relative order,
+// leaving out the longest run of vertices that kept their relative order.
+func Moved[T comparable](previous, order []T) []T {
+	index := map[T]int{}
+	for i, u := range previous {
+		index[u] = i
+	}
+	var shared []T
+	for _, u := range order {
+		if _, known := index[u]; known {
+			shared = append(shared, u)
+		}
+	}
+
+	// tails[k] is the position in shared ending the best run of length k+1
+	var tails []int
+	parent := make([]int, len(shared))
+	for i, u := range shared {
+		k := sort.Search(len(tails), func(k int) bool {
+			return index[shared[tails[k]]] >= index[u]
+		})
+		parent[i] = -1
+		if k > 0 {
+			parent[i] = tails[k-1]
+		}
+		if k == len(tails) {
+			tails = append(tails, i)
+		} else {
+			tails[k] = i
+		}
+	}
+
+	kept := NewSet[T]()
+	if len(tails) > 0 {
+		for i := tails[len(tails)-1]; i >= 0; i = parent[i] {
+			kept.Add(shared[i])
+		}
+	}
+	var moved []T
+	for _, u := range shared {
+		if !kept.Has(u) {
+			moved = append(moved, u)
+		}
+	}
+	return moved
+}
@@ -0,0 +1,56 @@
+package lib
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestGraph_StableOrder(t *testing.T) {
+	g := NewGraph[string]()
+	g.AddEdge("a", "b")
+	g.AddEdge("d", "b")
+	g.AddVertex("c")
+	g.AddVertex("e")
+	g.AddVertex("new")
+	g.Less = func(a, b string) bool {
+		return a < b
+	}
+
+	order, moved, err := g.StableOrder([]string{"a", "b", "c", "d", "e", "gone"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "[a c d b e new]"
+	if fmt.Sprint(order) != expected {
+		t.Fatal("expecting", expected, "got", order)
+	}
+	if fmt.Sprint(moved) != "[b]" {
+		t.Fatal("expecting [b] to move, got", moved)
+	}
+
+	g.AddEdge("b", "a")
+	_, _, err = g.StableOrder(nil)
+	if _, ok := err.(*CycleError[string]); !ok {
+		t.Fatal("expecting cycle error")
+	}
+}
+
+func TestMoved(t *testing.T) {
+	cases := []struct {
+		previous []int
+		order    []int
+		expected string
+	}{
+		{[]int{1, 2, 3}, []int{1, 2, 3}, "[]"},
+		{[]int{1, 2, 3}, []int{3, 1, 2}, "[3]"},
+		{[]int{1, 2, 3, 4}, []int{4, 3, 2, 1}, "[4 3 2]"},
+		{[]int{1, 2, 3}, []int{2, 5, 1}, "[2]"},
+		{nil, []int{1, 2}, "[]"},
+	}
+	for _, c := range cases {
+		moved := Moved(c.previous, c.order)
+		if fmt.Sprint(moved) != c.expected {
+			t.Fatal("expecting", c.expected, "got", moved)
+		}
+	}
+}